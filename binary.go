@@ -0,0 +1,548 @@
+package jsonaux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// ErrMalformedBinary is returned when CBOR or MessagePack input is malformed.
+var ErrMalformedBinary = errors.New("jsonaux: malformed binary input")
+
+// ErrUnrepresentable is returned when CBOR or MessagePack input holds a value
+// having no JSON equivalent, such as NaN, or a map key which is an array or
+// map.
+var ErrUnrepresentable = errors.New("jsonaux: value not representable as JSON")
+
+// FormatCBOR is like Format, but reads a CBOR (RFC 8949) value from r. Byte
+// strings are written as base64-encoded strings, as json.Marshal encodes
+// []byte, and bignums as numbers; other tags are ignored, leaving the values
+// they enclose. Undefined is written as null, and map keys which are not
+// strings as the JSON text of the key, such as "1" for an integer. The limits
+// set by WithMaxDepth, WithMaxTokenSize and WithMaxInputBytes apply as they
+// do to JSON input, the token size being that of the string as decoded.
+func FormatCBOR(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+	return format(w, limitDecoder(&cborSource{binarySource: newBinarySource(r, c)}, c), c)
+}
+
+// FormatMsgPack is like Format, but reads a MessagePack value from r. Binary
+// and extension values are written as base64-encoded strings, as
+// json.Marshal encodes []byte, except that timestamps are written as RFC 3339
+// strings. Map keys which are not strings are written as the JSON text of
+// the key, such as "1" for an integer. The limits apply as for FormatCBOR.
+func FormatMsgPack(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+	return format(w, limitDecoder(&msgpackSource{binarySource: newBinarySource(r, c)}, c), c)
+}
+
+// binarySource holds the state common to the token sources for binary input.
+type binarySource struct {
+	r        *bufio.Reader
+	count    *countReader
+	stack    []binaryFrame
+	maxToken int
+}
+
+// binaryFrame tracks the position within an array or map.
+type binaryFrame struct {
+	object bool
+	n      int  // items remaining, counting keys and values, or -1 if indefinite
+	key    bool // whether the next item is a key
+}
+
+func newBinarySource(r io.Reader, c config) binarySource {
+	if c.maxInput > 0 {
+		r = &limitReader{r: r, n: c.maxInput}
+	}
+	cr := &countReader{r: r}
+	return binarySource{r: bufio.NewReader(cr), count: cr, maxToken: c.maxToken}
+}
+
+// InputOffset returns the offset of the input following the last item read.
+func (b *binarySource) InputOffset() int64 { return b.count.n - int64(b.r.Buffered()) }
+
+// done reports whether the innermost composite has no items remaining.
+func (b *binarySource) done() bool {
+	n := len(b.stack)
+	return n > 0 && b.stack[n-1].n == 0
+}
+
+// push begins a composite holding n items, or if n is negative, holding
+// items up to a break.
+func (b *binarySource) push(object bool, n int) json.Token {
+	b.stack = append(b.stack, binaryFrame{object: object, n: n, key: object})
+	if object {
+		return json.Delim('{')
+	}
+	return json.Delim('[')
+}
+
+// pop ends the innermost composite.
+func (b *binarySource) pop() json.Token {
+	f := b.stack[len(b.stack)-1]
+	b.stack = b.stack[:len(b.stack)-1]
+	if f.object {
+		return json.Delim('}')
+	}
+	return json.Delim(']')
+}
+
+// item records that an item is to be read, reporting whether it is a key.
+func (b *binarySource) item() bool {
+	n := len(b.stack)
+	if n == 0 {
+		return false
+	}
+	f := &b.stack[n-1]
+	if f.n > 0 {
+		f.n--
+	}
+	key := f.key
+	f.key = f.object && !f.key
+	return key
+}
+
+// more reports whether input remains at the top level, where the innermost
+// composite has items remaining.
+func (b *binarySource) more() bool {
+	if len(b.stack) == 0 {
+		_, err := b.r.Peek(1)
+		return err == nil
+	}
+	return !b.done()
+}
+
+// header reads the first byte of an item.
+func (b *binarySource) header() (byte, error) {
+	c, err := b.r.ReadByte()
+	if err == io.EOF && len(b.stack) > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return c, err
+}
+
+// readUint reads an n-byte big-endian unsigned integer.
+func (b *binarySource) readUint(n int) (uint64, error) {
+	var buf [8]byte
+	_, err := io.ReadFull(b.r, buf[8-n:])
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return binary.BigEndian.Uint64(buf[:]), err
+}
+
+// readBytes reads n bytes, appending them to buf.
+func (b *binarySource) readBytes(buf []byte, n uint64) ([]byte, error) {
+	if b.maxToken > 0 && uint64(len(buf))+n > uint64(b.maxToken) {
+		return nil, ErrTokenTooLarge
+	}
+	w := bytes.NewBuffer(buf)
+	_, err := io.CopyN(w, b.r, int64(min(n, math.MaxInt64)))
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return w.Bytes(), err
+}
+
+// floatToken returns the number token for f.
+func floatToken(f float64, bits int) (json.Token, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, ErrUnrepresentable
+	}
+	return json.Number(strconv.FormatFloat(f, 'g', -1, bits)), nil
+}
+
+// binaryKey returns the object key for the item t, read as a key.
+func binaryKey(t json.Token, err error) (json.Token, error) {
+	if err != nil {
+		return nil, err
+	}
+	switch t := t.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return string(t), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	case nil:
+		return "null", nil
+	}
+	return nil, ErrUnrepresentable
+}
+
+// cborSource is a TokenSource reading CBOR.
+type cborSource struct {
+	binarySource
+}
+
+func (d *cborSource) More() bool {
+	n := len(d.stack)
+	if n > 0 && d.stack[n-1].n < 0 {
+		c, err := d.r.Peek(1)
+		return err == nil && c[0] != 0xff
+	}
+	return d.more()
+}
+
+func (d *cborSource) Token() (json.Token, error) {
+	if d.done() {
+		return d.pop(), nil
+	}
+	if n := len(d.stack); n > 0 && d.stack[n-1].n < 0 {
+		c, err := d.r.Peek(1)
+		if err == nil && c[0] == 0xff {
+			d.r.ReadByte()
+			if f := d.stack[n-1]; f.object && !f.key {
+				return nil, ErrMalformedBinary // a key without a value
+			}
+			return d.pop(), nil
+		}
+	}
+	if d.item() {
+		return binaryKey(d.value(true))
+	}
+	return d.value(false)
+}
+
+// value reads an item, reporting whether it is a key.
+func (d *cborSource) value(key bool) (json.Token, error) {
+	for {
+		c, err := d.header()
+		if err != nil {
+			return nil, err
+		}
+		major, info := c>>5, c&0x1f
+		if major == 7 {
+			return d.simple(info)
+		}
+		arg, indefinite, err := d.arg(info)
+		switch {
+		case err != nil:
+			return nil, err
+		case indefinite && (major < 2 || major > 5):
+			return nil, ErrMalformedBinary
+		case (major == 4 || major == 5) && key:
+			return nil, ErrUnrepresentable
+		}
+		switch major {
+		case 0:
+			return json.Number(strconv.FormatUint(arg, 10)), nil
+		case 1:
+			if arg < math.MaxInt64 {
+				return json.Number(strconv.FormatInt(-1-int64(arg), 10)), nil
+			}
+			n := new(big.Int).SetUint64(arg)
+			return json.Number(n.Not(n).String()), nil
+		case 2, 3:
+			b, err := d.str(major, arg, indefinite)
+			if err != nil {
+				return nil, err
+			}
+			if major == 2 {
+				return base64.StdEncoding.EncodeToString(b), nil
+			}
+			return string(b), nil
+		case 4, 5:
+			n := -1
+			if !indefinite {
+				if arg > math.MaxInt/2 {
+					return nil, ErrMalformedBinary
+				}
+				n = int(arg)
+				if major == 5 {
+					n *= 2
+				}
+			}
+			return d.push(major == 5, n), nil
+		}
+		// a tag
+		if arg == 2 || arg == 3 {
+			return d.bignum(arg == 3)
+		}
+	}
+}
+
+// arg reads the argument of an item, reporting whether its length is
+// indefinite.
+func (d *cborSource) arg(info byte) (uint64, bool, error) {
+	switch {
+	case info < 24:
+		return uint64(info), false, nil
+	case info <= 27:
+		n, err := d.readUint(1 << (info - 24))
+		return n, false, err
+	case info == 31:
+		return 0, true, nil
+	}
+	return 0, false, ErrMalformedBinary
+}
+
+// str reads the contents of a byte or text string of the given major type.
+func (d *cborSource) str(major byte, n uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		return d.readBytes(nil, n)
+	}
+	var b []byte
+	for {
+		c, err := d.header()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return nil, err
+		}
+		if c == 0xff {
+			return b, nil
+		}
+		if c>>5 != major {
+			return nil, ErrMalformedBinary
+		}
+		n, indefinite, err := d.arg(c & 0x1f)
+		if err == nil && indefinite {
+			err = ErrMalformedBinary
+		}
+		if err != nil {
+			return nil, err
+		}
+		b, err = d.readBytes(b, n)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// bignum reads the byte string of a bignum.
+func (d *cborSource) bignum(negative bool) (json.Token, error) {
+	c, err := d.header()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c>>5 != 2 {
+		return nil, ErrMalformedBinary
+	}
+	arg, indefinite, err := d.arg(c & 0x1f)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.str(2, arg, indefinite)
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).SetBytes(b)
+	if negative {
+		n.Not(n)
+	}
+	return json.Number(n.String()), nil
+}
+
+// simple reads a simple value or floating-point number.
+func (d *cborSource) simple(info byte) (json.Token, error) {
+	switch info {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22, 23:
+		return nil, nil
+	case 25:
+		n, err := d.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return floatToken(half(uint16(n)), 32)
+	case 26:
+		n, err := d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return floatToken(float64(math.Float32frombits(uint32(n))), 32)
+	case 27:
+		n, err := d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return floatToken(math.Float64frombits(n), 64)
+	case 31:
+		return nil, ErrMalformedBinary // a break outside of any composite
+	}
+	return nil, ErrUnrepresentable
+}
+
+// half decodes an IEEE 754 half-precision number.
+func half(h uint16) float64 {
+	exp, mant := int(h>>10&0x1f), float64(h&0x3ff)
+	var f float64
+	switch exp {
+	case 0:
+		f = math.Ldexp(mant, -24)
+	case 31:
+		f = math.Inf(1)
+		if mant != 0 {
+			f = math.NaN()
+		}
+	default:
+		f = math.Ldexp(mant+1024, exp-25)
+	}
+	if h&0x8000 != 0 {
+		f = -f
+	}
+	return f
+}
+
+// msgpackSource is a TokenSource reading MessagePack.
+type msgpackSource struct {
+	binarySource
+}
+
+func (d *msgpackSource) More() bool { return d.more() }
+
+func (d *msgpackSource) Token() (json.Token, error) {
+	if d.done() {
+		return d.pop(), nil
+	}
+	if d.item() {
+		return binaryKey(d.value(true))
+	}
+	return d.value(false)
+}
+
+// value reads an item, reporting whether it is a key.
+func (d *msgpackSource) value(key bool) (json.Token, error) {
+	c, err := d.header()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case c <= 0x7f:
+		return json.Number(strconv.Itoa(int(c))), nil
+	case c >= 0xe0:
+		return json.Number(strconv.Itoa(int(int8(c)))), nil
+	case c <= 0x8f:
+		return d.composite(key, true, uint64(c&0x0f))
+	case c <= 0x9f:
+		return d.composite(key, false, uint64(c&0x0f))
+	case c <= 0xbf:
+		return d.str(uint64(c & 0x1f))
+	}
+
+	var n uint64
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xca:
+		n, err = d.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return floatToken(float64(math.Float32frombits(uint32(n))), 32)
+	case 0xcb:
+		n, err = d.readUint(8)
+		if err != nil {
+			return nil, err
+		}
+		return floatToken(math.Float64frombits(n), 64)
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		n, err = d.readUint(1 << (c - 0xcc))
+		return json.Number(strconv.FormatUint(n, 10)), err
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		n, err = d.readUint(size)
+		shift := 64 - 8*size
+		return json.Number(strconv.FormatInt(int64(n<<shift)>>shift, 10)), err
+	case 0xc4, 0xc5, 0xc6:
+		n, err = d.readUint(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.readBytes(nil, n)
+		return base64.StdEncoding.EncodeToString(b), err
+	case 0xc7, 0xc8, 0xc9:
+		n, err = d.readUint(1 << (c - 0xc7))
+		if err != nil {
+			return nil, err
+		}
+		return d.ext(n)
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		return d.ext(1 << (c - 0xd4))
+	case 0xd9, 0xda, 0xdb:
+		n, err = d.readUint(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.str(n)
+	case 0xdc, 0xdd, 0xde, 0xdf:
+		n, err = d.readUint(2 << ((c - 0xdc) & 1))
+		if err != nil {
+			return nil, err
+		}
+		return d.composite(key, c >= 0xde, n)
+	}
+	return nil, ErrMalformedBinary // 0xc1
+}
+
+// composite begins an array or map holding n elements or members.
+func (d *msgpackSource) composite(key, object bool, n uint64) (json.Token, error) {
+	switch {
+	case key:
+		return nil, ErrUnrepresentable
+	case n > math.MaxInt/2:
+		return nil, ErrMalformedBinary
+	}
+	items := int(n)
+	if object {
+		items *= 2
+	}
+	return d.push(object, items), nil
+}
+
+// str reads a string of n bytes.
+func (d *msgpackSource) str(n uint64) (json.Token, error) {
+	b, err := d.readBytes(nil, n)
+	return string(b), err
+}
+
+// ext reads an extension value having n bytes of data.
+func (d *msgpackSource) ext(n uint64) (json.Token, error) {
+	typ, err := d.readUint(1)
+	if err != nil {
+		return nil, err
+	}
+	b, err := d.readBytes(nil, n)
+	if err != nil {
+		return nil, err
+	}
+	if int8(typ) != -1 {
+		return base64.StdEncoding.EncodeToString(b), nil
+	}
+
+	var sec, nsec int64
+	switch len(b) {
+	case 4:
+		sec = int64(binary.BigEndian.Uint32(b))
+	case 8:
+		v := binary.BigEndian.Uint64(b)
+		sec, nsec = int64(v&(1<<34-1)), int64(v>>34)
+	case 12:
+		nsec = int64(binary.BigEndian.Uint32(b))
+		sec = int64(binary.BigEndian.Uint64(b[4:]))
+	default:
+		return nil, ErrMalformedBinary
+	}
+	return time.Unix(sec, nsec).UTC().Format(time.RFC3339Nano), nil
+}
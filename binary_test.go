@@ -0,0 +1,223 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/binary"
+	enchex "encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// binaryTest is a case for a binary format, given in hexadecimal.
+type binaryTest struct {
+	in   string
+	want string // the minified output, without a trailing newline
+}
+
+// fromHex decodes the hexadecimal test input s.
+func fromHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := enchex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("bad test input %q: %v", s, err)
+	}
+	return b
+}
+
+func testBinary(t *testing.T, name string, format func(io.Writer, io.Reader, ...Option) error, tests []binaryTest) {
+	t.Helper()
+	for _, tt := range tests {
+		var b strings.Builder
+		err := format(&b, bytes.NewReader(fromHex(t, tt.in)), WithMinify(true), WithTrailingNewline(false))
+		if err != nil || b.String() != tt.want {
+			t.Errorf("%s(%s) = %q, %v, want %q", name, tt.in, b.String(), err, tt.want)
+		}
+	}
+}
+
+func TestFormatCBOR(t *testing.T) {
+	// mostly from RFC 8949, appendix A
+	testBinary(t, "FormatCBOR", FormatCBOR, []binaryTest{
+		// unsigned and negative integers
+		{"00", "0"},
+		{"17", "23"},
+		{"1818", "24"},
+		{"1b000000e8d4a51000", "1000000000000"},
+		{"1bffffffffffffffff", "18446744073709551615"},
+		{"20", "-1"},
+		{"3903e7", "-1000"},
+		{"3bffffffffffffffff", "-18446744073709551616"},
+		// byte and text strings, definite and indefinite
+		{"4401020304", `"AQIDBA=="`},
+		{"5f42010243030405ff", `"AQIDBAU="`},
+		{"6449455446", `"IETF"`},
+		{"62c3bc", `"ü"`},
+		{"7f657374726561646d696e67ff", `"streaming"`},
+		// arrays and maps, definite and indefinite
+		{"80", "[]"},
+		{"83010203", "[1,2,3]"},
+		{"8301820203820405", "[1,[2,3],[4,5]]"},
+		{"9fff", "[]"},
+		{"9f018202039f0405ffff", "[1,[2,3],[4,5]]"},
+		{"a0", "{}"},
+		{"a26161016162820203", `{"a":1,"b":[2,3]}`},
+		{"bf61610161629f0203ffff", `{"a":1,"b":[2,3]}`},
+		// keys which are not strings
+		{"a201020304", `{"1":2,"3":4}`},
+		{"a2f5f4f600", `{"true":false,"null":0}`},
+		// tags, including bignums
+		{"c074323031332d30332d32315432303a30343a30305a", `"2013-03-21T20:04:00Z"`},
+		{"c11a514b67b0", "1363896240"},
+		{"c249010000000000000000", "18446744073709551616"},
+		{"c349010000000000000000", "-18446744073709551617"},
+		// simple values and floats
+		{"f4", "false"},
+		{"f5", "true"},
+		{"f6", "null"},
+		{"f7", "null"},
+		{"f90000", "0"},
+		{"f93c00", "1"},
+		{"f93e00", "1.5"},
+		{"f97bff", "65504"},
+		{"f90001", "5.9604645e-08"},
+		{"fa47c35000", "100000"},
+		{"fb3ff199999999999a", "1.1"},
+	})
+}
+
+func TestFormatCBORErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"", ErrEmptyInput},
+		// truncated
+		{"83", io.ErrUnexpectedEOF},
+		{"8301", io.ErrUnexpectedEOF},
+		{"19ff", io.ErrUnexpectedEOF},
+		{"6449", io.ErrUnexpectedEOF},
+		{"bf61ff", io.ErrUnexpectedEOF},
+		{"c2", io.ErrUnexpectedEOF},
+		// malformed
+		{"1c", ErrMalformedBinary},
+		{"1f", ErrMalformedBinary},
+		{"ff", ErrMalformedBinary},
+		{"bf6161ff", ErrMalformedBinary},
+		{"5f6161ff", ErrMalformedBinary},
+		{"c201", ErrMalformedBinary},
+		// not representable
+		{"fa7fc00000", ErrUnrepresentable},
+		{"f97c00", ErrUnrepresentable},
+		{"a18000", ErrUnrepresentable},
+		{"f0", ErrUnrepresentable},
+	}
+	for _, tt := range tests {
+		err := FormatCBOR(io.Discard, bytes.NewReader(fromHex(t, tt.in)))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("FormatCBOR(%s) = %v, want %v", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestFormatMsgPack(t *testing.T) {
+	testBinary(t, "FormatMsgPack", FormatMsgPack, []binaryTest{
+		// integers
+		{"00", "0"},
+		{"7f", "127"},
+		{"ff", "-1"},
+		{"e0", "-32"},
+		{"cc80", "128"},
+		{"cdffff", "65535"},
+		{"cfffffffffffffffff", "18446744073709551615"},
+		{"d0ff", "-1"},
+		{"d080", "-128"},
+		{"d1ff7f", "-129"},
+		{"d3ffffffffffffffff", "-1"},
+		// floats and literals
+		{"ca3fc00000", "1.5"},
+		{"cb3ff199999999999a", "1.1"},
+		{"c0", "null"},
+		{"c2", "false"},
+		{"c3", "true"},
+		// strings and binary
+		{"a3616263", `"abc"`},
+		{"d903616263", `"abc"`},
+		{"c40301ff02", `"Af8C"`},
+		// arrays and maps
+		{"90", "[]"},
+		{"93010203", "[1,2,3]"},
+		{"dc0002c0c3", "[null,true]"},
+		{"80", "{}"},
+		{"82a16101a16292c0c2", `{"a":1,"b":[null,false]}`},
+		// keys which are not strings
+		{"8101c3", `{"1":true}`},
+		{"82c3c0c001", `{"true":null,"null":1}`},
+		// extensions, including timestamps
+		{"d40105", `"BQ=="`},
+		{"d6ff00000000", `"1970-01-01T00:00:00Z"`},
+		{"d7ff0000000400000001", `"1970-01-01T00:00:01.000000001Z"`},
+		{"c70cff000000010000000000000002", `"1970-01-01T00:00:02.000000001Z"`},
+	})
+}
+
+func TestFormatMsgPackErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+	}{
+		{"", ErrEmptyInput},
+		// truncated
+		{"92", io.ErrUnexpectedEOF},
+		{"9201", io.ErrUnexpectedEOF},
+		{"a5616263", io.ErrUnexpectedEOF},
+		{"cd01", io.ErrUnexpectedEOF},
+		{"81a161", io.ErrUnexpectedEOF},
+		// malformed
+		{"c1", ErrMalformedBinary},
+		{"d6ff0000", io.ErrUnexpectedEOF},
+		{"d5ff0000", ErrMalformedBinary},
+		// not representable
+		{"8191c0c0", ErrUnrepresentable},
+		{"cb7ff0000000000000", ErrUnrepresentable},
+	}
+	for _, tt := range tests {
+		err := FormatMsgPack(io.Discard, bytes.NewReader(fromHex(t, tt.in)))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("FormatMsgPack(%s) = %v, want %v", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestBinaryLimits(t *testing.T) {
+	// [[["abcd"]]] in each format
+	cbor, msgpack := fromHex(t, "81818164"+"61626364"), fromHex(t, "919191a4"+"61626364")
+	var long bytes.Buffer
+	long.WriteByte(0xdb)
+	binary.Write(&long, binary.BigEndian, uint32(1000))
+	long.WriteString(strings.Repeat("x", 1000))
+	tests := []struct {
+		opt  Option
+		want error
+		path string
+	}{
+		{WithMaxDepth(2), ErrTooDeep, "/0/0"},
+		{WithMaxTokenSize(3), ErrTokenTooLarge, "/0/0/0"},
+		{WithMaxInputBytes(6), ErrInputTooLarge, "/0/0/0"},
+	}
+	for _, tt := range tests {
+		for name, err := range map[string]error{
+			"FormatCBOR":    FormatCBOR(io.Discard, bytes.NewReader(cbor), tt.opt),
+			"FormatMsgPack": FormatMsgPack(io.Discard, bytes.NewReader(msgpack), tt.opt),
+		} {
+			var pe *PathError
+			if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.want) {
+				t.Errorf("%s: got %v, want %v at %q", name, err, tt.want, tt.path)
+			}
+		}
+	}
+	if err := FormatMsgPack(io.Discard, &long, WithMaxTokenSize(100)); !errors.Is(err, ErrTokenTooLarge) {
+		t.Errorf("FormatMsgPack of a long string: got %v, want %v", err, ErrTokenTooLarge)
+	}
+}
@@ -0,0 +1,36 @@
+package jsonaux
+
+import "bytes"
+
+// Indent is like json.Indent, appending to dst the single value held by src,
+// but formatted as Format would with opts, rather than with a prefix and
+// indentation given as arguments. It fails with ErrTrailingData should src
+// hold more than the value and surrounding whitespace. On error, dst is left
+// unchanged.
+func Indent(dst *bytes.Buffer, src []byte, opts ...Option) error {
+	n := dst.Len()
+	err := canonical(dst, bytes.NewReader(src), newConfig(opts))
+	if err != nil {
+		dst.Truncate(n)
+	}
+	return err
+}
+
+// Compact is like json.Compact, appending to dst the single value held by
+// src with insignificant whitespace removed, and without a trailing newline.
+// Strings are encoded as Format encodes them, which may differ from their
+// spelling in src. On error, dst is left unchanged.
+func Compact(dst *bytes.Buffer, src []byte) error {
+	return Indent(dst, src, WithMinify(true), WithTrailingNewline(false))
+}
+
+// AppendFormat is like Indent, but appends the formatted value to dst and
+// returns the extended slice. On error, it returns dst unextended.
+func AppendFormat(dst, src []byte, opts ...Option) ([]byte, error) {
+	b := bytes.NewBuffer(dst)
+	err := canonical(b, bytes.NewReader(src), newConfig(opts))
+	if err != nil {
+		return dst, err
+	}
+	return b.Bytes(), nil
+}
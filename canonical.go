@@ -0,0 +1,168 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// Key returns a representation of the single value read from r which is
+// identical for equal values, making it suitable for use as a map key. It is
+// the canonical form written by Canonicalize, and so disregards whitespace,
+// object member order, the escaping of strings, and the spelling of numbers,
+// so that 1.0, 1 and 1e0 produce the same key. Numbers are compared as IEEE
+// 754 doubles, so integers beyond 2**53 may collide, and those beyond its
+// range fail with ErrNumberRange. Duplicate members are retained, in their
+// input order.
+func Key(r io.Reader) (string, error) {
+	var b strings.Builder
+	err := canonical(&b, r, canonicalConfig())
+	if err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// Equal reports whether the single values read from a and b are equal, in
+// the sense that their keys as returned by Key are equal. In particular,
+// numbers are compared by value, so 1.0 and 1 are equal. Once a difference
+// is found, the remainder of b is not read, and so any error it may hold is
+// not reported.
+func Equal(a, b io.Reader) (bool, error) {
+	var buf bytes.Buffer
+	err := canonical(&buf, a, canonicalConfig())
+	if err != nil {
+		return false, err
+	}
+	m := &matcher{want: buf.Bytes()}
+	err = canonical(m, b, canonicalConfig())
+	if err == errMismatch {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return len(m.want) == 0, nil
+}
+
+var errMismatch = errors.New("jsonaux: values differ")
+
+// matcher consumes the expected output as it is written, failing with
+// errMismatch upon any difference.
+type matcher struct {
+	want []byte
+}
+
+func (m *matcher) Write(p []byte) (int, error) {
+	if !bytes.HasPrefix(m.want, p) {
+		return 0, errMismatch
+	}
+	m.want = m.want[len(p):]
+	return len(p), nil
+}
+
+// ErrNumberRange is returned, wrapped in a *PathError, when a number cannot
+// be represented in canonical form, lying beyond the range of an IEEE 754
+// double.
+var ErrNumberRange = errors.New("jsonaux: number out of range")
+
+// Canonicalize writes the single value read from r to w in the form defined
+// by the JSON Canonicalization Scheme (RFC 8785), suitable for hashing and
+// signing. Object members are sorted by the UTF-16 code units of their keys,
+// numbers are written as by ECMAScript, and strings with minimal escaping, in
+// UTF-8. No trailing newline is written. Duplicate keys, which the scheme
+// does not permit, are retained in their input order.
+func Canonicalize(w io.Writer, r io.Reader) error {
+	return canonical(w, r, canonicalConfig())
+}
+
+// canonicalConfig returns the configuration used by Canonicalize, Key and
+// Equal.
+func canonicalConfig() config {
+	return newConfig([]Option{
+		WithMinify(true),
+		WithSortKeys(true),
+		WithKeyComparator(compareUTF16),
+		WithEscapeJSSeparators(false),
+		WithTrailingNewline(false),
+		func(c *config) { c.canonical, c.noHTML = true, true },
+	})
+}
+
+// compareUTF16 compares a and b by their UTF-16 code units.
+func compareUTF16(a, b string) int {
+	x, y := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(x) && i < len(y); i++ {
+		if x[i] != y[i] {
+			return compareInts(int(x[i]), int(y[i]))
+		}
+	}
+	return compareInts(len(x), len(y))
+}
+
+// canonicalNumber returns n as ECMAScript would convert the nearest double to
+// a string.
+func canonicalNumber(n json.Number) (json.Number, error) {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrNumberRange, truncate(string(n), 32))
+	}
+	if f == 0 {
+		return "0", nil
+	}
+	// the shortest digits which round-trip, and the decimal exponent e,
+	// such that f is 0.digits * 10**e
+	b := strconv.AppendFloat(nil, f, 'e', -1, 64)
+	sign := ""
+	if b[0] == '-' {
+		sign, b = "-", b[1:]
+	}
+	mant, exp, _ := strings.Cut(string(b), "e")
+	digits := strings.Replace(mant, ".", "", 1)
+	e, _ := strconv.Atoi(exp)
+	e++
+	k := len(digits)
+	var out string
+	switch {
+	case k <= e && e <= 21:
+		out = digits + strings.Repeat("0", e-k)
+	case 0 < e && e <= 21:
+		out = digits[:e] + "." + digits[e:]
+	case -6 < e && e <= 0:
+		out = "0." + strings.Repeat("0", -e) + digits
+	default:
+		out = digits[:1]
+		if k > 1 {
+			out += "." + digits[1:]
+		}
+		out += "e"
+		if e > 0 {
+			out += "+"
+		}
+		out += strconv.Itoa(e - 1)
+	}
+	return json.Number(sign + out), nil
+}
+
+// canonical writes the single value read from r, as configured by c, failing
+// if r holds anything more.
+func canonical(w io.Writer, r io.Reader, c config) error {
+	dec := newDecoder(r, c)
+	err := format(w, dec, c)
+	if err != nil {
+		return err
+	}
+	_, err = dec.Token()
+	switch err {
+	case nil:
+		return ErrTrailingData
+	case io.EOF:
+		return nil
+	}
+	return err
+}
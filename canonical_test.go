@@ -0,0 +1,204 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		a, b string
+		same bool
+	}{
+		{`{"b":[1,{"y":2,"x":"a"}],"a":null}`, " {\"a\":null,\n\"b\":[1,{\"x\":\"a\",\"y\":2}]}\n", true},
+		{`{"a":1.0}`, `{"a":1}`, true},
+		{`[1e0,10,0.5]`, `[1,1e1,5e-1]`, true},
+		{`-0`, `0`, true},
+		{`"a\/"`, `"a/"`, true},
+		{`{"a":1}`, `{"a":1}`, true},
+		{`[1,2]`, `[2,1]`, false},
+		{`1`, `"1"`, false},
+		{`{"a":1,"a":2}`, `{"a":2,"a":1}`, false},
+	}
+	for _, tt := range tests {
+		a, err := Key(strings.NewReader(tt.a))
+		if err != nil {
+			t.Fatalf("Key(%s): %v", tt.a, err)
+		}
+		b, err := Key(strings.NewReader(tt.b))
+		if err != nil {
+			t.Fatalf("Key(%s): %v", tt.b, err)
+		}
+		if (a == b) != tt.same {
+			t.Errorf("Key(%s) = %q, Key(%s) = %q, want same %v", tt.a, a, tt.b, b, tt.same)
+		}
+	}
+
+	for _, in := range []string{"", "{", "1 2", "[1,]"} {
+		if k, err := Key(strings.NewReader(in)); err == nil {
+			t.Errorf("Key(%q) = %q, want an error", in, k)
+		}
+	}
+	if _, err := Key(strings.NewReader(`[1e400]`)); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("Key of an out of range number: %v, want %v", err, ErrNumberRange)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a":1,"b":[2]}`, ` { "b" : [2], "a":1}`, true},
+		{`{"a":1}`, `{"a":1,"b":2}`, false},
+		{`{"a":1,"b":2}`, `{"a":1}`, false},
+		{`1.0`, `1`, true},
+		{`[1E2,-0,0.10]`, `[100,0,1e-1]`, true},
+		{`1.5`, `1.25`, false},
+		{`"\u0061"`, `"a"`, true},
+		{`{"\u0061":"\u00e9"}`, `{"a":"é"}`, true},
+		{`"\uD83D\uDE00"`, `"😀"`, true},
+		{`"a"`, `"b"`, false},
+		{`[1,2]`, `[2,1]`, false},
+		{`null`, `false`, false},
+	}
+	for _, tt := range tests {
+		got, err := Equal(strings.NewReader(tt.a), strings.NewReader(tt.b))
+		if err != nil || got != tt.want {
+			t.Errorf("Equal(%s, %s) = %v, %v, want %v", tt.a, tt.b, got, err, tt.want)
+		}
+	}
+
+	for _, tt := range [][2]string{{`[1`, `[1]`}, {`[1]`, `[1`}, {`1`, `1 2`}} {
+		if _, err := Equal(strings.NewReader(tt[0]), strings.NewReader(tt[1])); err == nil {
+			t.Errorf("Equal(%s, %s) succeeded, want an error", tt[0], tt[1])
+		}
+	}
+}
+
+func TestCanonicalNumbers(t *testing.T) {
+	// RFC 8785, appendix B
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0xffefffffffffffff, "-1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x44b52d02c7e14af7, "1.0000000000000001e+23"},
+		{0x444b1ae4d6e2ef4e, "999999999999999700000"},
+		{0x444b1ae4d6e2ef4f, "999999999999999900000"},
+		{0x444b1ae4d6e2ef50, "1e+21"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x41b3de4355555554, "333333333.33333325"},
+		{0x41b3de4355555555, "333333333.3333333"},
+		{0x41b3de4355555556, "333333333.3333334"},
+		{0x41b3de4355555557, "333333333.33333343"},
+		{0xbecbf647612f3696, "-0.0000033333333333333333"},
+		{0x43143ff3c1cb0959, "1424953923781206.2"},
+	}
+	for _, tt := range tests {
+		in := strconv.FormatFloat(math.Float64frombits(tt.bits), 'g', -1, 64)
+		got, err := canonicalNumber(json.Number(in))
+		if err != nil || string(got) != tt.want {
+			t.Errorf("canonicalNumber(%s) = %s, %v, want %s", in, got, err, tt.want)
+		}
+	}
+
+	// spellings of numbers, rather than their values
+	for in, want := range map[string]string{
+		"-0":                            "0",
+		"1.0":                           "1",
+		"4.50":                          "4.5",
+		"2e-3":                          "0.002",
+		"1E30":                          "1e+30",
+		"1e20":                          "100000000000000000000",
+		"0.0000001":                     "1e-7",
+		"9007199254740993":              "9007199254740992",
+		"1e-400":                        "0",
+		"0.000000000000000000000000001": "1e-27",
+	} {
+		got, err := canonicalNumber(json.Number(in))
+		if err != nil || string(got) != want {
+			t.Errorf("canonicalNumber(%s) = %s, %v, want %s", in, got, err, want)
+		}
+	}
+	if _, err := canonicalNumber("1e400"); !errors.Is(err, ErrNumberRange) {
+		t.Errorf("canonicalNumber(1e400): %v, want %v", err, ErrNumberRange)
+	}
+}
+
+func TestCanonicalize(t *testing.T) {
+	// RFC 8785, section 3.2.2
+	const in = `{
+  "numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`
+	const want = `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	var b strings.Builder
+	if err := Canonicalize(&b, strings.NewReader(in)); err != nil || b.String() != want {
+		t.Errorf("Canonicalize = %s, %v\nwant %s", b.String(), err, want)
+	}
+
+	for _, in := range []string{`[1e999]`, `[1] 2`, ``} {
+		if err := Canonicalize(new(strings.Builder), strings.NewReader(in)); err == nil {
+			t.Errorf("Canonicalize(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestCanonicalizeKeyOrder(t *testing.T) {
+	// RFC 8785, section 3.2.3
+	const in = `{
+  "€": "Euro Sign",
+  "\r": "Carriage Return",
+  "דּ": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "😀": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "ö": "Latin Small Letter O With Diaeresis"
+}`
+	want := []string{
+		"Carriage Return",
+		"One",
+		"Control",
+		"Latin Small Letter O With Diaeresis",
+		"Euro Sign",
+		"Emoji: Grinning Face",
+		"Hebrew Letter Dalet With Dagesh",
+	}
+	var b strings.Builder
+	if err := Canonicalize(&b, strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	dec := json.NewDecoder(strings.NewReader(b.String()))
+	dec.Token() // this will be '{'
+	for dec.More() {
+		dec.Token() // the key
+		v, err := dec.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v.(string))
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Canonicalize ordered the values as %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,115 @@
+package jsonaux
+
+import "io"
+
+// ColorMode determines whether output is colored using ANSI escape
+// sequences, for display on a terminal.
+type ColorMode uint8
+
+const (
+	// ColorNever produces plain output. It is the default.
+	ColorNever ColorMode = iota
+
+	// ColorAlways colors the output unconditionally.
+	ColorAlways
+
+	// ColorAuto colors the output only when writing to a terminal,
+	// producing plain output otherwise, such as when piped to another
+	// program. It is the recommended mode for command line tools. A
+	// terminal is detected only when the writer is an *os.File; on
+	// platforms other than Linux, macOS, the BSDs and Windows, any
+	// character device, such as /dev/null, is taken to be one.
+	ColorAuto
+)
+
+// WithColorMode selects whether output is colored.
+func WithColorMode(m ColorMode) Option {
+	return func(c *config) { c.colorMode = m }
+}
+
+// Colors holds the SGR parameters of the ANSI escape sequences used for
+// each class of output when coloring, such as "1;34" for bold blue, or
+// "38;5;208" for an orange from the 256-color palette. An empty string leaves
+// that class uncolored.
+type Colors struct {
+	Key     string // object keys
+	String  string // string values
+	Number  string
+	Literal string // true, false, and null
+	Punct   string // braces, brackets, commas, and colons
+}
+
+// WithColors sets the colors used when output is colored, as enabled by
+// WithColorMode. By default, keys are bold blue, strings green, numbers cyan,
+// literals magenta, and punctuation uncolored.
+func WithColors(colors Colors) Option {
+	return func(c *config) {
+		c.palette = &palette{
+			styleKey:     colors.Key,
+			styleString:  colors.String,
+			styleNumber:  colors.Number,
+			styleLiteral: colors.Literal,
+			stylePunct:   colors.Punct,
+		}
+	}
+}
+
+// style identifies a class of output which may be colored.
+type style uint8
+
+const (
+	styleKey style = iota
+	styleString
+	styleNumber
+	styleLiteral // true, false, and null
+	stylePunct
+	numStyles
+)
+
+// palette holds the SGR parameters used for each style, such as "1;34" for
+// bold blue. An empty string leaves that style uncolored.
+type palette [numStyles]string
+
+var defaultPalette = palette{
+	styleKey:     "1;34",
+	styleString:  "32",
+	styleNumber:  "36",
+	styleLiteral: "35",
+}
+
+// coloring reports whether output to w is to be colored.
+func (c *config) coloring(w io.Writer) bool {
+	switch c.colorMode {
+	case ColorAlways:
+		return true
+	case ColorAuto:
+		tty, _ := isTerminal(w)
+		return tty
+	}
+	return false
+}
+
+// colors returns the palette to be used when coloring.
+func (c *config) colors() *palette {
+	if c.palette != nil {
+		return c.palette
+	}
+	return &defaultPalette
+}
+
+// paint begins output in the given style.
+func (s *state) paint(st style) {
+	if s.colors != nil && s.colors[st] != "" {
+		// written directly, as they occupy no columns
+		s.Writer.WriteString("\x1b[")
+		s.Writer.WriteString(s.colors[st])
+		s.Writer.WriteByte('m')
+	}
+}
+
+// unpaint ends output in the given style.
+func (s *state) unpaint(st style) {
+	if s.colors != nil && s.colors[st] != "" {
+		s.Writer.WriteString("\x1b[0m")
+	}
+}
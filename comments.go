@@ -0,0 +1,63 @@
+package jsonaux
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WithPathComments controls whether each scalar within an object or array is
+// followed by a line comment holding its JSON Pointer (RFC 6901), such as
+// // /items/0/name, as a debugging aid. A pointer holding control characters,
+// which could end the comment early, is instead written as a JSON string, as
+// in // "/a\nb". The result is JSONC rather than JSON, and is not accepted by
+// standard parsers. Comments are not emitted for minified output, nor for
+// composites laid out on a single line, as by WithCompactArraysOfScalars or
+// WithLineWidth.
+func WithPathComments(enable bool) Option {
+	return func(c *config) { c.pathComments = enable }
+}
+
+// commentDecoder is a decoder reading through a lenientReader which keeps
+// line comments, for WithPreserveComments.
+type commentDecoder struct {
+	decoder
+	r *lenientReader
+}
+
+// keepComment notes that the scalar just read may be followed by a line
+// comment, which is read only once the input following it has been.
+func (s *state) keepComment() {
+	if d, ok := s.tokenSource.(*commentDecoder); ok {
+		s.trailing, s.trailingEnd = d.r, d.InputOffset()
+	}
+}
+
+// flushComment writes any pending comment, which ends the current line.
+func (s *state) flushComment() {
+	if s.trailing != nil {
+		if text := s.trailing.take(s.trailingEnd); text != "" {
+			if s.comment != "" {
+				s.comment += " // "
+			}
+			s.comment += text
+		}
+		s.trailing = nil
+	}
+	if s.comment == "" {
+		return
+	}
+	s.WriteString(" // ")
+	if strings.IndexFunc(s.comment, breaksComment) >= 0 {
+		s.buf = appendString(s.buf[:0], s.comment, &config{noHTML: true})
+		s.Write(s.buf)
+	} else {
+		s.WriteString(s.comment)
+	}
+	s.comment = ""
+}
+
+// breaksComment reports whether r may not appear within a line comment, as a
+// control character or a line terminator in JavaScript.
+func breaksComment(r rune) bool {
+	return unicode.IsControl(r) || r == '\u2028' || r == '\u2029'
+}
@@ -0,0 +1,129 @@
+package jsonaux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPathComments(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts []Option
+		want string
+	}{
+		{"pointers", `{"a":[1,{"b/c~":true}],"d":"x"}`, nil, `{ "a": 
+  [ 1 // /a/0
+  , { "b/c~": true // /a/1/b~1c~0
+    }
+  ]
+, "d": "x" // /d
+}
+`},
+		{"control characters", `{"a\nb":1,"c\td":2}`, nil, `{ "a\nb": 1 // "/a\nb"
+, "c\td": 2 // "/c\td"
+}
+`},
+		{"compact array", `{"a":[1,2],"b":3}`, []Option{WithCompactArraysOfScalars(true)}, `{ "a": [1, 2]
+, "b": 3 // /b
+}
+`},
+		{"line width", `{"a":{"x":1},"b":[1,2,3,4,5,6,7,8,9,10,11,12,13,14,15,16,17,18,19,20]}`, []Option{WithLineWidth(20)}, `{ "a": {"x": 1}
+, "b": 
+  [ 1 // /b/0
+  , 2 // /b/1
+  , 3 // /b/2
+  , 4 // /b/3
+  , 5 // /b/4
+  , 6 // /b/5
+  , 7 // /b/6
+  , 8 // /b/7
+  , 9 // /b/8
+  , 10 // /b/9
+  , 11 // /b/10
+  , 12 // /b/11
+  , 13 // /b/12
+  , 14 // /b/13
+  , 15 // /b/14
+  , 16 // /b/15
+  , 17 // /b/16
+  , 18 // /b/17
+  , 19 // /b/18
+  , 20 // /b/19
+  ]
+}
+`},
+		{"minified", `{"a":[1]}`, []Option{WithMinify(true)}, "{\"a\":[1]}\n"},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithPathComments(true)}, tt.opts...)
+		if got := formatString(t, tt.in, opts...); got != tt.want {
+			t.Errorf("%s: Format =\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPreserveComments(t *testing.T) {
+	const in = `{
+  // leading, discarded
+  "a": 1, // one
+  "b": [true, // two
+    null /* block, discarded */, "x" // three
+  ],
+  "c": // after a key, discarded
+    2
+}`
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"prefix", nil, `{ "a": 1 // one
+, "b": 
+  [ true // two
+  , null
+  , "x" // three
+  ]
+, "c": 2
+}
+`},
+		{"suffix", []Option{WithCommaStyle(CommaSuffix)}, `{
+  "a": 1, // one
+  "b": [
+    true, // two
+    null,
+    "x" // three
+  ],
+  "c": 2
+}
+`},
+		{"lenient", []Option{WithLenientInput(true)}, `{ "a": 1 // one
+, "b": 
+  [ true // two
+  , null
+  , "x" // three
+  ]
+, "c": 2
+}
+`},
+		{"path comments", []Option{WithPathComments(true), WithCompactArraysOfScalars(true)}, `{ "a": 1 // /a // one
+, "b": [true, null, "x"]
+, "c": 2 // /c
+}
+`},
+		{"minified", []Option{WithMinify(true)}, "{\"a\":1,\"b\":[true,null,\"x\"],\"c\":2}\n"},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithPreserveComments(true)}, tt.opts...)
+		if got := formatString(t, in, opts...); got != tt.want {
+			t.Errorf("%s: Format =\n%s\nwant:\n%s", tt.name, got, tt.want)
+		}
+	}
+
+	// without a comment following it, a comma remains an error
+	var b strings.Builder
+	err := Format(&b, strings.NewReader("[1,]"), WithPreserveComments(true))
+	if err == nil {
+		t.Errorf("Format of a trailing comma = %q, want an error", b.String())
+	}
+}
@@ -0,0 +1,55 @@
+package jsonaux
+
+import "encoding/json"
+
+// WithCompactArraysOfScalars controls whether arrays containing only scalars
+// are laid out on a single line, as in [1, 2, 3], while objects and other
+// arrays are expanded as usual. Deciding the layout of an array requires its
+// leading scalar elements to be buffered until either the end of the array
+// or a composite element is reached.
+func WithCompactArraysOfScalars(compact bool) Option {
+	return func(c *config) { c.compact = compact }
+}
+
+func (s *state) compactArray() error {
+	src := s.tokenSource
+	defer func() { s.tokenSource = src }()
+
+	var toks []json.Token
+	for s.More() {
+		t, err := s.token()
+		if err != nil {
+			return err
+		}
+		toks = append(toks, t)
+		if _, ok := t.(json.Delim); ok {
+			s.tokenSource = &chain{replay{toks}, src}
+			return s.stream('[')
+		}
+	}
+	s.tokenSource = &chain{replay{toks}, src}
+	s.flat = s.depth() + 1
+	defer func() { s.flat = 0 }()
+	return s.stream('[')
+}
+
+// chain is a tokenSource yielding buffered tokens before continuing with
+// those of src.
+type chain struct {
+	buf replay
+	src tokenSource
+}
+
+func (c *chain) Token() (json.Token, error) {
+	if len(c.buf.toks) > 0 {
+		return c.buf.Token()
+	}
+	return c.src.Token()
+}
+
+func (c *chain) More() bool {
+	if len(c.buf.toks) > 0 {
+		return c.buf.More()
+	}
+	return c.src.More()
+}
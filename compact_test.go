@@ -0,0 +1,54 @@
+package jsonaux
+
+import "testing"
+
+func TestCompactArraysOfScalars(t *testing.T) {
+	const in = `{"name":"grid","size":[3,3],"rows":[[1,2,3],[4,5,6]],"opts":{"tags":["a","b"],"empty":[],"mixed":[1,{"x":true}]}}`
+	tests := []struct {
+		style CommaStyle
+		want  string
+	}{
+		{CommaPrefix, `{ "name": "grid"
+, "size": [3, 3]
+, "rows": 
+  [ [1, 2, 3]
+  , [4, 5, 6]
+  ]
+, "opts": 
+  { "tags": ["a", "b"]
+  , "empty": []
+  , "mixed": 
+    [ 1
+    , { "x": true
+      }
+    ]
+  }
+}
+`},
+		{CommaSuffix, `{
+  "name": "grid",
+  "size": [3, 3],
+  "rows": [
+    [1, 2, 3],
+    [4, 5, 6]
+  ],
+  "opts": {
+    "tags": ["a", "b"],
+    "empty": [],
+    "mixed": [
+      1,
+      {
+        "x": true
+      }
+    ]
+  }
+}
+`},
+	}
+	for _, tt := range tests {
+		got := formatString(t, in, WithCompactArraysOfScalars(true), WithCommaStyle(tt.style))
+		if got != tt.want {
+			t.Errorf("style %d: Format =\n%s\nwant:\n%s", tt.style, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package jsonaux
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// checkInterval is the number of tokens read between checks for
+// cancellation or an expired time budget.
+const checkInterval = 256
+
+// FormatContext is like Format, but stops with the context's error if ctx is
+// done before formatting completes.
+func FormatContext(ctx context.Context, w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	s.ctx = ctx
+	return s.document()
+}
+
+// WithTimeout bounds the time spent formatting, failing with
+// context.DeadlineExceeded once d has elapsed. The budget is only checked
+// between tokens, and then only periodically, so a single large token, such
+// as a very long string, or a stalled reader may cause it to be overrun.
+func WithTimeout(d time.Duration) Option {
+	return func(c *config) { c.timeout = d }
+}
+
+// check periodically reports whether formatting should stop early.
+func (s *state) check() error {
+	s.ntok++
+	if s.out != nil && s.out.err != nil {
+		return s.out.err
+	}
+	if s.ntok%checkInterval != 0 {
+		return nil
+	}
+	if s.ctx != nil {
+		err := s.ctx.Err()
+		if err != nil {
+			return err
+		}
+	}
+	if !s.deadline.IsZero() && time.Now().After(s.deadline) {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
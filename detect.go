@@ -0,0 +1,77 @@
+package jsonaux
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// detectLimit bounds the amount of input inspected by the Detect functions.
+const detectLimit = 64 << 10
+
+// DetectIndent inspects the beginning of already formatted input, and
+// reports the unit of indentation it uses, such as two or four spaces, or a
+// tab, for use with WithIndent. The unit is taken to be the leading
+// whitespace of the first indented line. If no indented line is found within
+// the inspected prefix, as with minified input, the empty string is
+// returned. Validity of the input is not checked.
+func DetectIndent(r io.Reader) (string, error) {
+	br := bufio.NewReader(io.LimitReader(r, detectLimit))
+	for {
+		line, err := br.ReadBytes('\n')
+		rest := bytes.TrimLeft(line, " \t")
+		if n := len(line) - len(rest); n > 0 && len(bytes.TrimSpace(rest)) > 0 {
+			return string(line[:n]), nil
+		}
+		if err == io.EOF {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// DetectCommaStyle inspects the beginning of already formatted input, and
+// reports the comma style it uses, for use with WithCommaStyle. The style is
+// taken from the first line which either begins or ends with a comma,
+// ignoring surrounding whitespace. If there is none within the inspected
+// prefix, as with minified input, CommaPrefix is returned. Validity of the
+// input is not checked, so a string spanning lines may mislead it.
+func DetectCommaStyle(r io.Reader) (CommaStyle, error) {
+	br := bufio.NewReader(io.LimitReader(r, detectLimit))
+	for {
+		line, err := br.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+		switch {
+		case bytes.HasPrefix(line, []byte(",")):
+			return CommaPrefix, nil
+		case bytes.HasSuffix(line, []byte(",")):
+			return CommaSuffix, nil
+		}
+		if err == io.EOF {
+			return CommaPrefix, nil
+		}
+		if err != nil {
+			return CommaPrefix, err
+		}
+	}
+}
+
+// FormatPreservingStyle is like Format, but retains the comma style and unit
+// of indentation of already formatted input, as reported by DetectCommaStyle
+// and DetectIndent, unless overridden by opts.
+func FormatPreservingStyle(w io.Writer, r io.Reader, opts ...Option) error {
+	prefix, err := io.ReadAll(io.LimitReader(r, detectLimit))
+	if err != nil {
+		return err
+	}
+	cs, _ := DetectCommaStyle(bytes.NewReader(prefix))
+	indent, _ := DetectIndent(bytes.NewReader(prefix))
+	detected := []Option{WithCommaStyle(cs)}
+	if indent != "" {
+		detected = append(detected, WithIndent(indent))
+	}
+	r = io.MultiReader(bytes.NewReader(prefix), r)
+	return Format(w, r, append(detected, opts...)...)
+}
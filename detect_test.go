@@ -0,0 +1,36 @@
+package jsonaux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetectIndent(t *testing.T) {
+	tests := []struct{ name, in, want string }{
+		{"tab", "{\n\t\"a\": [\n\t\t1\n\t]\n}\n", "\t"},
+		{"two spaces", "{\n  \"a\": 1\n}\n", "  "},
+		{"four spaces", "{\n    \"a\": {\n        \"b\": 1\n    }\n}\n", "    "},
+		{"eight spaces", "[\n        1,\n        2\n]\n", "        "},
+		{"comma prefix", "{ \"a\": \n  { \"b\": 1\n  }\n}\n", "  "},
+		{"blank lines first", "{\n\n   \n    \"a\": 1\n}\n", "    "},
+		{"minified", `{"a":[1,2]}`, ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := DetectIndent(strings.NewReader(tt.in))
+		if err != nil || got != tt.want {
+			t.Errorf("%s: DetectIndent = %q, %v, want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestFormatPreservingIndent(t *testing.T) {
+	for _, indent := range []string{"\t", "    "} {
+		in := formatString(t, `{"a":[1,{"b":2}]}`, WithCommaStyle(CommaSuffix), WithIndent(indent))
+		var b strings.Builder
+		err := FormatPreservingStyle(&b, strings.NewReader(in))
+		if err != nil || b.String() != in {
+			t.Errorf("indent %q: FormatPreservingStyle = %q, %v, want %q", indent, b.String(), err, in)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package jsonaux
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WithDiffContext sets the number of unchanged lines shown around each change
+// by UnifiedDiff. The default is 3.
+func WithDiffContext(n int) Option {
+	return func(c *config) { c.diffContext = n }
+}
+
+// UnifiedDiff formats the values read from a and b, and writes a unified
+// diff of the results to w, suitable for existing diff viewers. Both values
+// are formatted with opts and with object keys sorted, so that the diff
+// reflects differences in content rather than in whitespace or key order.
+// Nothing is written if the formatted values are identical. When coloring is
+// enabled by WithColorMode, deleted lines are shown in red, and inserted lines
+// in green.
+func UnifiedDiff(w io.Writer, a, b io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+	c.sortKeys = true
+	color := c.coloring(w)
+	c.colorMode = ColorNever
+
+	var fa, fb bytes.Buffer
+	err := format(&fa, newDecoder(a, c), c)
+	if err != nil {
+		return err
+	}
+	err = format(&fb, newDecoder(b, c), c)
+	if err != nil {
+		return err
+	}
+
+	edits := diffLines(splitLines(fa.String()), splitLines(fb.String()))
+	bw := bufio.NewWriter(w)
+	writeUnified(bw, edits, c.diffContext, color)
+	return bw.Flush()
+}
+
+func splitLines(s string) []string {
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// edit is a single line of a diff: unchanged (' '), deleted ('-'), or
+// inserted ('+').
+type edit struct {
+	op   byte
+	line string
+}
+
+// diffLines computes a minimal edit script transforming a into b, using the
+// algorithm described in Myers' "An O(ND) Difference Algorithm and Its
+// Variations".
+func diffLines(a, b []string) []edit {
+	n, m := len(a), len(b)
+	max := n + m
+	off := max + 1
+	v := make([]int, 2*max+3)
+	var trace [][]int
+
+search:
+	for d := 0; d <= max; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+		var pk int
+		if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+			pk = k + 1
+		} else {
+			pk = k - 1
+		}
+		px := v[off+pk]
+		py := px - pk
+		for x > px && y > py {
+			x--
+			y--
+			edits = append(edits, edit{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == px {
+			y--
+			edits = append(edits, edit{'+', b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{'-', a[x]})
+		}
+	}
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
+
+// writeUnified writes edits as unified diff hunks, each surrounded by up to
+// ctx unchanged lines.
+func writeUnified(w *bufio.Writer, edits []edit, ctx int, color bool) {
+	if ctx < 0 {
+		ctx = 0
+	}
+	header := false
+	ai, bi := 0, 0 // lines of a and b preceding edits[i]
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			ai++
+			bi++
+			i++
+			continue
+		}
+		if !header {
+			writeDiffLine(w, "1", "--- a", color)
+			writeDiffLine(w, "1", "+++ b", color)
+			header = true
+		}
+
+		// extend the hunk until ctx*2 unchanged lines separate changes
+		start := i - ctx
+		if start < 0 {
+			start = 0
+		}
+		end, same := i, 0
+		for j := i; j < len(edits) && same <= 2*ctx; j++ {
+			if edits[j].op == ' ' {
+				same++
+				continue
+			}
+			same = 0
+			end = j + 1
+		}
+		stop := end + ctx
+		if stop > len(edits) {
+			stop = len(edits)
+		}
+
+		as, bs := ai-(i-start), bi-(i-start)
+		var al, bl int
+		for _, e := range edits[start:stop] {
+			if e.op != '+' {
+				al++
+			}
+			if e.op != '-' {
+				bl++
+			}
+		}
+		hunk := fmt.Sprintf("@@ -%s +%s @@", hunkRange(as, al), hunkRange(bs, bl))
+		writeDiffLine(w, "36", hunk, color)
+		for _, e := range edits[start:stop] {
+			writeDiffLine(w, diffColors[e.op], string(e.op)+e.line, color)
+		}
+
+		for _, e := range edits[i:stop] {
+			if e.op != '+' {
+				ai++
+			}
+			if e.op != '-' {
+				bi++
+			}
+		}
+		i = stop
+	}
+}
+
+// diffColors holds the SGR parameters used for each kind of edit.
+var diffColors = map[byte]string{'-': "31", '+': "32"}
+
+// writeDiffLine writes a line of a diff, colored with the given SGR
+// parameters if color is set.
+func writeDiffLine(w *bufio.Writer, sgr, line string, color bool) {
+	if color && sgr != "" {
+		line = "\x1b[" + sgr + "m" + line + "\x1b[0m"
+	}
+	w.WriteString(line)
+	w.WriteByte('\n')
+}
+
+// hunkRange formats the range of n lines following the first pos lines.
+func hunkRange(pos, n int) string {
+	if n == 0 {
+		return fmt.Sprintf("%d,0", pos)
+	}
+	if n == 1 {
+		return fmt.Sprint(pos + 1)
+	}
+	return fmt.Sprintf("%d,%d", pos+1, n)
+}
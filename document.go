@@ -0,0 +1,161 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Document is an in-memory representation of a JSON value. Unlike the result
+// of unmarshaling into an interface{}, it retains the order of object members
+// and the original spelling of numbers.
+type Document struct {
+	// Token holds a scalar value as produced by json.Decoder with UseNumber
+	// set: nil, a bool, a string, or a json.Number. For composite values it
+	// holds json.Delim('{') or json.Delim('[').
+	Token json.Token
+
+	// Members holds the members of an object, in input order.
+	Members []Member
+
+	// Elements holds the elements of an array.
+	Elements []*Document
+}
+
+// Member is a single member of an object Document.
+type Member struct {
+	Key   string
+	Value *Document
+}
+
+// ParseDocument reads a single JSON value from r.
+func ParseDocument(r io.Reader) (*Document, error) {
+	return readDocument(newDecoder(r, newConfig(nil)))
+}
+
+// Format writes d to w in the manner of Format. Numbers are written exactly
+// as spelled in the input from which d was parsed, unless altered by an
+// option such as WithQuoteBigInts.
+func (d *Document) Format(w io.Writer, opts ...Option) error {
+	c := newConfig(opts)
+	return format(w, d.replay(), c)
+}
+
+// IsObject reports whether d holds an object.
+func (d *Document) IsObject() bool { return d.Token == json.Delim('{') }
+
+// IsArray reports whether d holds an array.
+func (d *Document) IsArray() bool { return d.Token == json.Delim('[') }
+
+func readDocument(src tokenSource) (*Document, error) {
+	t, err := src.Token()
+	if err != nil {
+		return nil, err
+	}
+	return readRest(src, t)
+}
+
+// Lookup returns the value of the member of d having the given key, or nil
+// if d is not an object or has no such member. If the key is duplicated, the
+// last such member is used, consistent with json.Unmarshal.
+func (d *Document) Lookup(key string) *Document {
+	for i := len(d.Members) - 1; i >= 0; i-- {
+		if d.Members[i].Key == key {
+			return d.Members[i].Value
+		}
+	}
+	return nil
+}
+
+// readRest reads the remainder of the value beginning with t.
+func readRest(src tokenSource, t json.Token) (*Document, error) {
+	var err error
+	d := &Document{Token: t}
+	switch t {
+	case json.Delim('{'):
+		for src.More() {
+			t, err = src.Token()
+			if err != nil {
+				return nil, err
+			}
+			v, err := readDocument(src)
+			if err != nil {
+				return nil, err
+			}
+			d.Members = append(d.Members, Member{Key: t.(string), Value: v})
+		}
+	case json.Delim('['):
+		for src.More() {
+			v, err := readDocument(src)
+			if err != nil {
+				return nil, err
+			}
+			d.Elements = append(d.Elements, v)
+		}
+	default:
+		if _, ok := t.(json.Delim); ok {
+			return nil, fmt.Errorf("impossible state: %q", t)
+		}
+		return d, nil
+	}
+	// this will be '}' or ']'
+	_, err = src.Token()
+	return d, err
+}
+
+// tokens appends the token stream representing d to buf.
+func (d *Document) tokens(buf []json.Token) []json.Token {
+	buf = append(buf, d.Token)
+	switch d.Token {
+	case json.Delim('{'):
+		for _, m := range d.Members {
+			buf = append(buf, m.Key)
+			buf = m.Value.tokens(buf)
+		}
+		buf = append(buf, json.Delim('}'))
+	case json.Delim('['):
+		for _, e := range d.Elements {
+			buf = e.tokens(buf)
+		}
+		buf = append(buf, json.Delim(']'))
+	}
+	return buf
+}
+
+// clone returns a deep copy of d.
+func (d *Document) clone() *Document {
+	c := &Document{Token: d.Token}
+	for _, m := range d.Members {
+		c.Members = append(c.Members, Member{m.Key, m.Value.clone()})
+	}
+	for _, e := range d.Elements {
+		c.Elements = append(c.Elements, e.clone())
+	}
+	return c
+}
+
+func (d *Document) replay() *replay {
+	return &replay{d.tokens(nil)}
+}
+
+// replay is a tokenSource yielding previously buffered tokens.
+type replay struct {
+	toks []json.Token
+}
+
+func (r *replay) Token() (json.Token, error) {
+	if len(r.toks) == 0 {
+		return nil, io.EOF
+	}
+	t := r.toks[0]
+	r.toks = r.toks[1:]
+	return t, nil
+}
+
+func (r *replay) More() bool {
+	if len(r.toks) == 0 {
+		return false
+	}
+	t := r.toks[0]
+	return t != json.Delim('}') && t != json.Delim(']')
+}
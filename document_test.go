@@ -0,0 +1,28 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDocumentNumbers(t *testing.T) {
+	const in = `{"a":1.0,"b":100000000000000000000,"c":1e10}`
+	d, err := ParseDocument(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range d.Members {
+		if _, ok := m.Value.Token.(json.Number); !ok {
+			t.Errorf("member %q holds %T, want json.Number", m.Key, m.Value.Token)
+		}
+	}
+	var b strings.Builder
+	err = d.Format(&b, WithMinify(true), WithTrailingNewline(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != in {
+		t.Errorf("Format = %s, want %s", got, in)
+	}
+}
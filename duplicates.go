@@ -0,0 +1,99 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// DuplicateKeys determines how an object holding a key more than once is
+// formatted.
+type DuplicateKeys uint8
+
+const (
+	// DuplicateKeysAllow emits every member, duplicates included.
+	DuplicateKeysAllow DuplicateKeys = iota
+
+	// DuplicateKeysError fails with ErrDuplicateKey.
+	DuplicateKeysError
+
+	// DuplicateKeysKeepFirst emits only the first member having each key.
+	DuplicateKeysKeepFirst
+
+	// DuplicateKeysKeepLast emits only the last member having each key, in
+	// its position. Each object is buffered in memory to find them.
+	DuplicateKeysKeepLast
+)
+
+// ErrDuplicateKey is returned, wrapped in a *PathError naming the repeated
+// member, when an object holds a key more than once under
+// DuplicateKeysError.
+var ErrDuplicateKey = errors.New("jsonaux: duplicate object key")
+
+// WithDuplicateKeys sets the policy for objects holding a key more than once.
+// Keys are compared as emitted, after any key mapper is applied, so that
+// distinct keys mapped to the same key are duplicates, as are "a" and
+// "\u0061". Members omitted by the key mapper are disregarded. The default is
+// DuplicateKeysAllow.
+func WithDuplicateKeys(d DuplicateKeys) Option {
+	return func(c *config) { c.dupKeys = d }
+}
+
+// checkDuplicate applies the duplicate key policy to the key just begun in
+// the current object, reporting whether its member should be emitted.
+func (s *state) checkDuplicate(k string) (bool, error) {
+	if s.dupKeys != DuplicateKeysError && s.dupKeys != DuplicateKeysKeepFirst {
+		return true, nil
+	}
+	f := &s.stack[len(s.stack)-1]
+	if _, ok := f.keys[k]; !ok {
+		if f.keys == nil {
+			f.keys = make(map[string]struct{})
+		}
+		f.keys[k] = struct{}{}
+		return true, nil
+	}
+	if s.dupKeys == DuplicateKeysKeepFirst {
+		return false, nil
+	}
+	err := ErrDuplicateKey
+	if d, ok := s.tokenSource.(decoder); ok {
+		err = fmt.Errorf("%w (input offset %d)", err, d.InputOffset())
+	}
+	return false, &PathError{Path: s.path(), Err: err}
+}
+
+// lastMembers formats the remainder of an object, omitting each member
+// whose key recurs later within it.
+func (s *state) lastMembers() error {
+	d, err := readRest(checkedSource{s}, json.Delim('{'))
+	if err != nil {
+		return err
+	}
+	s.keepLast(d)
+	return s.replay(d)
+}
+
+// keepLast removes from the object d, about to be formatted, each member
+// whose key as emitted recurs later.
+func (s *state) keepLast(d *Document) {
+	keys := make([]string, len(d.Members))
+	path := s.path()
+	for i, m := range d.Members {
+		keys[i] = m.Key
+		if s.keyMapper != nil {
+			keys[i] = s.keyMapper(path, m.Key)
+		}
+	}
+	last := make(map[string]int, len(d.Members))
+	for i, k := range keys {
+		last[k] = i
+	}
+	members := d.Members[:0]
+	for i, m := range d.Members {
+		if last[keys[i]] == i {
+			members = append(members, m)
+		}
+	}
+	d.Members = members
+}
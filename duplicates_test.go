@@ -0,0 +1,47 @@
+package jsonaux
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDuplicateKeysAfterMapping(t *testing.T) {
+	lower := WithKeyMapper(func(path, key string) string {
+		if key == "drop" {
+			return ""
+		}
+		return strings.ToLower(key)
+	})
+	const in = `{"a":1,"A":2,"drop":3,"b":4,"drop":5}`
+	tests := []struct {
+		d    DuplicateKeys
+		want string
+	}{
+		{DuplicateKeysAllow, `{"a":1,"a":2,"b":4}`},
+		{DuplicateKeysKeepFirst, `{"a":1,"b":4}`},
+		{DuplicateKeysKeepLast, `{"a":2,"b":4}`},
+	}
+	for _, tt := range tests {
+		got := formatString(t, in, lower, WithDuplicateKeys(tt.d), WithMinify(true))
+		if got != tt.want+"\n" {
+			t.Errorf("%v: got %q, want %q", tt.d, got, tt.want)
+		}
+	}
+	got := formatString(t, in, lower, WithDuplicateKeys(DuplicateKeysKeepLast), WithSortKeys(true), WithMinify(true))
+	if want := "{\"a\":2,\"b\":4}\n"; got != want {
+		t.Errorf("DuplicateKeysKeepLast, sorted: got %q, want %q", got, want)
+	}
+
+	var b strings.Builder
+	err := Format(&b, strings.NewReader(in), lower, WithDuplicateKeys(DuplicateKeysError))
+	var pe *PathError
+	if !errors.As(err, &pe) || pe.Path != "/A" || !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("DuplicateKeysError: got %v, want %v at /A", err, ErrDuplicateKey)
+	}
+	// members omitted by the mapper are not duplicates
+	err = Format(&b, strings.NewReader(`{"drop":1,"drop":2}`), lower, WithDuplicateKeys(DuplicateKeysError))
+	if err != nil {
+		t.Errorf("DuplicateKeysError with omitted members: %v", err)
+	}
+}
@@ -0,0 +1,16 @@
+package jsonaux
+
+import "strconv"
+
+// PathError records an error and the JSON Pointer (RFC 6901) of the value
+// being processed when it occurred.
+type PathError struct {
+	Path string
+	Err  error
+}
+
+func (e *PathError) Error() string {
+	return e.Err.Error() + " at " + strconv.Quote(e.Path)
+}
+
+func (e *PathError) Unwrap() error { return e.Err }
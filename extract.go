@@ -0,0 +1,121 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidPointer is returned when a JSON Pointer is malformed.
+var ErrInvalidPointer = errors.New("jsonaux: invalid JSON Pointer")
+
+// ErrNotFound is returned, wrapped in a *PathError, when no value exists at a
+// JSON Pointer.
+var ErrNotFound = errors.New("jsonaux: no value at pointer")
+
+// Extract reads a value from r, and formats to w only the value within it
+// identified by pointer, a JSON Pointer (RFC 6901) such as /items/3/name. The
+// empty pointer identifies the whole value. Values preceding the one sought
+// are skipped as they are read, without being held in memory, and the input
+// following it is not read at all. Should an object hold a key more than
+// once, the first occurrence is used.
+func Extract(w io.Writer, r io.Reader, pointer string, opts ...Option) error {
+	ref, err := parsePointer(pointer)
+	if err != nil {
+		return err
+	}
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	for i, name := range ref {
+		found, err := s.seek(name)
+		if err != nil {
+			return s.eof(err)
+		}
+		if !found {
+			return &PathError{Path: formatPointer(ref[:i+1]), Err: ErrNotFound}
+		}
+	}
+	return s.document()
+}
+
+// seek reads up to the member or element of the next value named by name,
+// reporting whether it exists.
+func (s *state) seek(name string) (bool, error) {
+	t, err := s.token()
+	if err != nil {
+		return false, err
+	}
+	switch t {
+	case json.Delim('{'):
+		for s.More() {
+			t, err = s.token()
+			if err != nil || t == name {
+				return err == nil, err
+			}
+			err = s.skip()
+			if err != nil {
+				return false, err
+			}
+		}
+	case json.Delim('['):
+		i, ok := index(name)
+		for n := 0; ok && s.More(); n++ {
+			if n == i {
+				return true, nil
+			}
+			err = s.skip()
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// index returns the array index named by a reference token, if any.
+func index(name string) (int, bool) {
+	if name == "" || name[0] == '+' || len(name) > 1 && name[0] == '0' {
+		return 0, false
+	}
+	i, err := strconv.Atoi(name)
+	return i, err == nil && i >= 0
+}
+
+// parsePointer returns the unescaped reference tokens of a JSON Pointer.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, ErrInvalidPointer
+	}
+	ref := strings.Split(p[1:], "/")
+	for i, name := range ref {
+		for j := 0; j < len(name); j++ {
+			if name[j] != '~' {
+				continue
+			}
+			if j+1 == len(name) || name[j+1] != '0' && name[j+1] != '1' {
+				return nil, ErrInvalidPointer
+			}
+			j++
+		}
+		ref[i] = pointerUnescaper.Replace(name)
+	}
+	return ref, nil
+}
+
+// formatPointer returns the JSON Pointer having the given reference tokens.
+func formatPointer(ref []string) string {
+	var b strings.Builder
+	for _, name := range ref {
+		b.WriteByte('/')
+		pointerEscaper.WriteString(&b, name)
+	}
+	return b.String()
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
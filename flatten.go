@@ -0,0 +1,232 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrFlattenConflict is returned by Unflatten, wrapped in a *PathError naming
+// the offending member, when a key cannot be placed consistently with those
+// preceding it, as with /a following /a/b.
+var ErrFlattenConflict = errors.New("jsonaux: conflicting flattened key")
+
+// ErrNotObject is returned when the top-level input value is required to be
+// an object, but is not.
+var ErrNotObject = errors.New("jsonaux: top-level value is not an object")
+
+// WithFlattenSeparator causes Flatten to join the reference tokens of each
+// path with sep, as in a.b.0 for a separator of ".", and Unflatten to split
+// keys by it, instead of using JSON Pointers. Reference tokens are not
+// escaped, so an object key containing sep is not restored faithfully. The
+// default, the empty string, uses JSON Pointers.
+func WithFlattenSeparator(sep string) Option {
+	return func(c *config) { c.flattenSep = sep }
+}
+
+// Flatten reads a value from r, and formats to w an object holding a member
+// for each scalar within it, whose key is the JSON Pointer of the scalar, and
+// whose value is the scalar. Empty objects and arrays are kept as values, so
+// that all of the structure is represented; a top-level scalar has the empty
+// pointer as its key. The value is flattened as it is read, without being
+// held in memory.
+func Flatten(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+	return format(w, &flattener{src: newDecoder(r, c), sep: c.flattenSep}, c)
+}
+
+// flattener is a tokenSource yielding the flattened form of the value read
+// from src.
+type flattener struct {
+	src   tokenSource
+	sep   string
+	path  []string
+	objs  []bool // whether each enclosing composite is an object
+	idx   []int  // the number of elements already seen in each
+	begun bool
+	queue []json.Token
+	err   error
+}
+
+func (f *flattener) Token() (json.Token, error) {
+	f.fill()
+	if len(f.queue) == 0 {
+		return nil, f.err
+	}
+	t := f.queue[0]
+	f.queue = f.queue[1:]
+	return t, nil
+}
+
+func (f *flattener) More() bool {
+	f.fill()
+	return len(f.queue) > 0 && f.queue[0] != json.Delim('}') && f.queue[0] != json.Delim(']')
+}
+
+// fill ensures that the queue holds a token, unless reading fails.
+func (f *flattener) fill() {
+	for len(f.queue) == 0 && f.err == nil {
+		f.err = f.step()
+	}
+}
+
+// step reads a key or value, queuing the resulting tokens, if any.
+func (f *flattener) step() error {
+	if !f.begun {
+		f.begun = true
+		f.queue = append(f.queue, json.Delim('{'))
+		return f.value()
+	}
+	n := len(f.objs)
+	if n == 0 {
+		return io.EOF
+	}
+	if !f.src.More() {
+		_, err := f.src.Token()
+		if err != nil {
+			return err
+		}
+		f.path, f.objs, f.idx = f.path[:n-1], f.objs[:n-1], f.idx[:n-1]
+		if n == 1 {
+			f.queue = append(f.queue, json.Delim('}'))
+		}
+		return nil
+	}
+	if f.objs[n-1] {
+		t, err := f.src.Token()
+		if err != nil {
+			return err
+		}
+		f.path[n-1] = t.(string)
+	} else {
+		f.path[n-1] = strconv.Itoa(f.idx[n-1])
+		f.idx[n-1]++
+	}
+	return f.value()
+}
+
+// value reads the next value, queuing the member holding it if it is a
+// scalar or empty.
+func (f *flattener) value() error {
+	t, err := f.src.Token()
+	if err != nil {
+		return err
+	}
+	d, ok := t.(json.Delim)
+	if !ok {
+		f.queue = append(f.queue, f.key(), t)
+		if len(f.objs) == 0 {
+			f.queue = append(f.queue, json.Delim('}'))
+		}
+		return nil
+	}
+	if f.src.More() {
+		f.path = append(f.path, "")
+		f.objs = append(f.objs, d == '{')
+		f.idx = append(f.idx, 0)
+		return nil
+	}
+	end, err := f.src.Token()
+	if err != nil {
+		return err
+	}
+	f.queue = append(f.queue, f.key(), d, end)
+	if len(f.objs) == 0 {
+		f.queue = append(f.queue, json.Delim('}'))
+	}
+	return nil
+}
+
+// key returns the key for the current path.
+func (f *flattener) key() string {
+	if f.sep == "" {
+		return formatPointer(f.path)
+	}
+	return strings.Join(f.path, f.sep)
+}
+
+// Unflatten reverses Flatten, reading an object holding a member for each
+// scalar, keyed by its path, and formatting to w the value they describe.
+// The object is held in memory to do so. A composite created for a path is
+// made an array if the path continues with index 0, and an object otherwise;
+// an array's elements must then be given in order. So an object whose keys
+// are 0, 1, and so on is restored as an array, as is usual, and not as it was
+// flattened. Should a key recur, the last occurrence is used.
+func Unflatten(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	flat, err := readDocument(newDecoder(r, c))
+	if err != nil {
+		return err
+	}
+	if !flat.IsObject() {
+		return ErrNotObject
+	}
+	var root *Document
+	for _, m := range flat.Members {
+		ref, err := parsePointer(m.Key)
+		if c.flattenSep != "" {
+			ref, err = strings.Split(m.Key, c.flattenSep), nil
+			if m.Key == "" {
+				ref = nil
+			}
+		}
+		if err == nil {
+			root, err = place(root, ref, m.Value)
+		}
+		if err != nil {
+			return &PathError{Path: formatPointer([]string{m.Key}), Err: err}
+		}
+	}
+	if root == nil {
+		root = &Document{Token: json.Delim('{')}
+	}
+	return format(w, root.replay(), c)
+}
+
+// place places v within d at ref, creating any composites missing along the
+// way, and returns the result.
+func place(d *Document, ref []string, v *Document) (*Document, error) {
+	if len(ref) == 0 {
+		if d != nil && (d.IsObject() || d.IsArray()) && d.Token != v.Token {
+			return nil, ErrFlattenConflict
+		}
+		return v, nil
+	}
+	name := ref[0]
+	switch {
+	case d == nil && name == "0":
+		d = &Document{Token: json.Delim('[')}
+	case d == nil:
+		d = &Document{Token: json.Delim('{')}
+	case d.IsObject():
+	case d.IsArray():
+	default:
+		return nil, ErrFlattenConflict
+	}
+
+	if d.IsArray() {
+		i, ok := index(name)
+		if !ok || i > len(d.Elements) {
+			return nil, ErrFlattenConflict
+		}
+		if i == len(d.Elements) {
+			d.Elements = append(d.Elements, nil)
+		}
+		e, err := place(d.Elements[i], ref[1:], v)
+		d.Elements[i] = e
+		return d, err
+	}
+	for i := len(d.Members) - 1; i >= 0; i-- {
+		if d.Members[i].Key == name {
+			e, err := place(d.Members[i].Value, ref[1:], v)
+			d.Members[i].Value = e
+			return d, err
+		}
+	}
+	e, err := place(nil, ref[1:], v)
+	d.Members = append(d.Members, Member{name, e})
+	return d, err
+}
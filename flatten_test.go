@@ -0,0 +1,138 @@
+package jsonaux
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// flattenString returns the minified output of f, either Flatten or
+// Unflatten, for in and the separator sep.
+func flattenString(f func(io.Writer, io.Reader, ...Option) error, in, sep string) (string, error) {
+	var b strings.Builder
+	err := f(&b, strings.NewReader(in), WithFlattenSeparator(sep), WithMinify(true), WithTrailingNewline(false))
+	return b.String(), err
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		in, sep string
+		want    string
+	}{
+		{`{"a":{"b":[1,{"c":true}],"e":{},"f":[]},"g":"x"}`, "",
+			`{"/a/b/0":1,"/a/b/1/c":true,"/a/e":{},"/a/f":[],"/g":"x"}`},
+		{`{"a":{"b":[1,{"c":true}],"e":{},"f":[]},"g":"x"}`, ".",
+			`{"a.b.0":1,"a.b.1.c":true,"a.e":{},"a.f":[],"g":"x"}`},
+		{`{"a/b":{"~":1},"":null,"c":{"":2}}`, "", `{"/a~1b/~0":1,"/":null,"/c/":2}`},
+		{`{"a.b":{"c":1}}`, ".", `{"a.b.c":1}`},
+		{`[[[]],[{}]]`, "", `{"/0/0":[],"/1/0":{}}`},
+		{`5`, "", `{"":5}`},
+		{`"x"`, ".", `{"":"x"}`},
+		{`[]`, "", `{"":[]}`},
+		{`{}`, "", `{"":{}}`},
+	}
+	for _, tt := range tests {
+		got, err := flattenString(Flatten, tt.in, tt.sep)
+		if err != nil || got != tt.want {
+			t.Errorf("Flatten(%s), separator %q = %s, %v, want %s", tt.in, tt.sep, got, err, tt.want)
+		}
+	}
+}
+
+func TestUnflatten(t *testing.T) {
+	tests := []struct {
+		in, sep string
+		want    string
+	}{
+		{`{"/a/b/0":1,"/a/b/1/c":true,"/a/e":{},"/g":"x"}`, "", `{"a":{"b":[1,{"c":true}],"e":{}},"g":"x"}`},
+		{`{"a.b.0":1,"a.b.1":2}`, ".", `{"a":{"b":[1,2]}}`},
+		{`{"a::b":1,"a::c":2}`, "::", `{"a":{"b":1,"c":2}}`},
+		{`{"":5}`, "", `5`},
+		{`{"":5}`, ".", `5`},
+		{`{"":[]}`, "", `[]`},
+		{`{}`, "", `{}`},
+		// an array is made for index 0, and an object otherwise
+		{`{"/0":1,"/1":2}`, "", `[1,2]`},
+		{`{"/1":1,"/0":2}`, "", `{"1":1,"0":2}`},
+		{`{"/a/-":1}`, "", `{"a":{"-":1}}`},
+		// a recurring key keeps the last value
+		{`{"/a":1,"/b":2,"/a":3}`, "", `{"a":3,"b":2}`},
+		// an empty composite is filled by later keys
+		{`{"/a":{},"/a/b":1}`, "", `{"a":{"b":1}}`},
+	}
+	for _, tt := range tests {
+		got, err := flattenString(Unflatten, tt.in, tt.sep)
+		if err != nil || got != tt.want {
+			t.Errorf("Unflatten(%s), separator %q = %s, %v, want %s", tt.in, tt.sep, got, err, tt.want)
+		}
+	}
+}
+
+func TestUnflattenErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error
+		path string
+	}{
+		{`{"/a":1,"/a/b":2}`, ErrFlattenConflict, "/~1a~1b"},
+		{`{"/a/b":1,"/a":2}`, ErrFlattenConflict, "/~1a"},
+		{`{"/a/0":1,"/a/x":2}`, ErrFlattenConflict, "/~1a~1x"},
+		{`{"/a/0":1,"/a/2":2}`, ErrFlattenConflict, "/~1a~12"},
+		{`{"a":1}`, ErrInvalidPointer, "/a"},
+	}
+	for _, tt := range tests {
+		err := Unflatten(new(strings.Builder), strings.NewReader(tt.in))
+		var pe *PathError
+		if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.want) {
+			t.Errorf("Unflatten(%s) = %v, want %v at %q", tt.in, err, tt.want, tt.path)
+		}
+	}
+	for _, in := range []string{`[1]`, `"/a"`, `null`} {
+		if err := Unflatten(new(strings.Builder), strings.NewReader(in)); err != ErrNotObject {
+			t.Errorf("Unflatten(%s) = %v, want %v", in, err, ErrNotObject)
+		}
+	}
+	for _, in := range []string{``, `{"/a":1`, `{"/a":}`} {
+		if err := Unflatten(new(strings.Builder), strings.NewReader(in)); err == nil {
+			t.Errorf("Unflatten(%q) succeeded, want an error", in)
+		}
+		if err := Flatten(new(strings.Builder), strings.NewReader(in)); err == nil {
+			t.Errorf("Flatten(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestFlattenRoundTrip(t *testing.T) {
+	tests := []struct {
+		in, sep string
+		want    string // the result of the round trip, if not in
+	}{
+		{`{"a":{"b":[1,{"c":true}],"e":{},"f":[]},"g":[[],[null]]}`, "", ""},
+		{`{"a":{"b":[1,{"c":true}],"e":{},"f":[]},"g":[[],[null]]}`, ".", ""},
+		{`[{"x":"y"},-1.5e3]`, "/", ""},
+		// keys containing the separator, which JSON Pointers escape
+		{`{"a/b":{"~":1,"~1":[2]},"/":{"c~/d":null},"":{"":3}}`, "", ""},
+		{`{"a.b":{"c":1}}`, "", ""},
+		// but other separators do not
+		{`{"a.b":{"c":1}}`, ".", `{"a":{"b":{"c":1}}}`},
+		{`{"a.b":1,"a":{"c":2}}`, ".", `{"a":{"b":1,"c":2}}`},
+		{`{"a/b":1}`, "/", `{"a":{"b":1}}`},
+		// and neither restores an object whose keys are indexes
+		{`{"0":"x","1":"y"}`, "", `["x","y"]`},
+	}
+	for _, tt := range tests {
+		flat, err := flattenString(Flatten, tt.in, tt.sep)
+		got := ""
+		if err == nil {
+			got, err = flattenString(Unflatten, flat, tt.sep)
+		}
+		want := tt.want
+		if want == "" {
+			want = tt.in
+		}
+		if err != nil || got != want {
+			t.Errorf("Unflatten(Flatten(%s)), separator %q = %s, %v, want %s", tt.in, tt.sep, got, err, want)
+		}
+	}
+}
@@ -4,48 +4,316 @@ package jsonaux
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
+	"time"
 )
 
-// Format transforms the input using a comma-prefix style. The particular
-// formatting should be considered opinionated and subject to change.
-func Format(w io.Writer, r io.Reader) error {
-	bw := bufio.NewWriter(w)
-	dec := json.NewDecoder(r)
-	dec.UseNumber()
+// Format transforms the input using a comma-prefix style, unless otherwise
+// specified by opts. The particular formatting should be considered
+// opinionated and subject to change.
+//
+// The output depends only on the structure and content of the input, never
+// on its insignificant whitespace: already indented input, whatever its
+// indentation, tabs, or line endings, formats exactly as its minified
+// equivalent does. Whitespace within strings is always preserved. Likewise,
+// object members are emitted in their input order unless an option which
+// sorts them is given, even when members are buffered for other reasons.
+// A top-level scalar is written alone, followed only by the trailing newline
+// if enabled; options concerning layout have no effect upon it. Malformed
+// input is reported as a *SyntaxError. With WithFilter, only the values
+// matched by the filter are formatted, as by Query.
+func Format(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+	if c.filter != "" {
+		return Query(w, r, c.filter, opts...)
+	}
+	return format(w, newDecoder(r, c), c)
+}
+
+// FormatTee is like Format, but writes the output to each of writers, while
+// formatting the input only once. Writes proceed in order, as with
+// io.MultiWriter; if any writer fails, formatting stops with its error, and
+// later writers may not have received the output written to earlier ones.
+func FormatTee(r io.Reader, writers ...io.Writer) error {
+	return Format(io.MultiWriter(writers...), r)
+}
+
+// FormatReadingOffset is like Format, but also returns the number of input
+// bytes comprising the value, through its final byte, so that input following
+// it may be handled separately. Whitespace after the value may or may not be
+// included. Reading from r is buffered, so it will usually have been read past
+// the offset; callers resuming at the offset should seek or retain the input.
+// If invalid UTF-8 is escaped, the offset counts each invalid byte as its
+// replacement's encoded length.
+func FormatReadingOffset(w io.Writer, r io.Reader, opts ...Option) (int64, error) {
+	c := newConfig(opts)
+	dec := newDecoder(r, c)
+	err := format(w, dec, c)
+	return dec.InputOffset(), err
+}
 
-	state := &state{Writer: bw, Decoder: dec, stack: make(stack, 0, 64)}
-	err := state.any()
+func format(w io.Writer, src tokenSource, c config) error {
+	return newState(w, src, c).document()
+}
+
+// document formats a single top-level value and flushes the output.
+func (s *state) document() error {
+	err := s.any()
 	if err != nil {
+		return s.eof(err)
+	}
+	return s.end()
+}
+
+// progress records the completion of a document, or of an element of a
+// top-level array, flushing the output if the flush interval is reached.
+func (s *state) progress() error {
+	s.ndoc++
+	if s.flushEvery > 0 && s.ndoc%s.flushEvery == 0 {
+		return s.Flush()
+	}
+	return nil
+}
+
+// ErrEmptyInput is returned when the input holds no value at all, being empty
+// or entirely whitespace. It wraps io.EOF.
+var ErrEmptyInput error = emptyInput{}
+
+type emptyInput struct{}
+
+func (emptyInput) Error() string { return "jsonaux: empty input" }
+func (emptyInput) Unwrap() error { return io.EOF }
+
+// eof distinguishes input which is empty from that which ends partway
+// through a value, which json.Decoder reports alike as io.EOF.
+func (s *state) eof(err error) error {
+	switch {
+	case err != io.EOF:
 		return err
+	case s.ntok <= 1:
+		return ErrEmptyInput
+	}
+	return io.ErrUnexpectedEOF
+}
+
+// end completes the output, and flushes it.
+func (s *state) end() error {
+	s.flushComment()
+	if s.newline {
+		s.WriteByte('\n')
 	}
-	bw.WriteByte('\n')
-	return bw.Flush()
+	return s.Flush()
+}
+
+// flushed flushes the output, so that any completed before an error is kept,
+// and returns err, or the error flushing if err is nil.
+func (s *state) flushed(err error) error {
+	ferr := s.Flush()
+	if err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// TokenSource is the subset of json.Decoder used by the formatter, through
+// which input in other forms may be formatted. Token must yield the tokens of
+// a value as json.Decoder does with UseNumber set, returning io.EOF once the
+// input is exhausted, and More must report whether the current object or
+// array holds another member or element.
+type TokenSource interface {
+	Token() (json.Token, error)
+	More() bool
+}
+
+type tokenSource = TokenSource
+
+// FormatTokens is like Format, but formats the value yielded by src.
+func FormatTokens(w io.Writer, src TokenSource, opts ...Option) error {
+	return format(w, src, newConfig(opts))
 }
 
 type state struct {
-	*bufio.Writer
-	*json.Decoder
-	min bool
+	output
+	tokenSource
+	config
 	stack
+	stats Statistics
+	buf   []byte
+	flat  int // depth of the outermost composite laid out on one line
+
+	newline bool     // whether to end the output with a newline
+	colors  *palette // escape sequences for each style, if coloring
+	comment string   // pending comment for the end of the current line
+
+	trailing    *lenientReader // the source of any input comment pending
+	trailingEnd int64          // the offset of the scalar it would follow
+
+	prefix string // the path of the value formatted, if within the input
+
+	ctx      context.Context
+	deadline time.Time
+	ntok     int
+	ndoc     int
+	out      *limitWriter // enforces the output size limit, if any
+}
+
+func newDecoder(r io.Reader, c config) decoder {
+	if c.maxInput > 0 {
+		r = &limitReader{r: r, n: c.maxInput}
+	}
+	var lr *lenientReader
+	if c.lenient || c.comments || c.keep {
+		lr = &lenientReader{r: bufio.NewReader(r), json5: c.lenient, keep: c.keep}
+		r = lr
+	}
+	if c.utf8 != UTF8Replace {
+		r = newUTF8Reader(r, c.utf8)
+	}
+	var dec decoder
+	if c.decoder == nil {
+		t := newTokenizer(r)
+		t.maxToken = c.maxToken
+		dec = t
+	} else {
+		pos := newPosReader(r)
+		jd := json.NewDecoder(pos)
+		jd.UseNumber()
+		c.decoder(jd)
+		dec = &jsonDecoder{jd, pos, c.maxToken}
+	}
+	dec = limitDecoder(dec, c)
+	if c.keep {
+		dec = &commentDecoder{dec, lr}
+	}
+	return dec
+}
+
+func newState(w io.Writer, src tokenSource, c config) *state {
+	s := &state{tokenSource: src, config: c, stack: make(stack, 0, 64)}
+	s.reset(w)
+	return s
+}
+
+// reset prepares s to format a new document to w, retaining its
+// configuration and reusing its buffers.
+func (s *state) reset(w io.Writer) {
+	dst := w
+	if s.maxOutput > 0 {
+		s.out = &limitWriter{w: w, n: s.maxOutput}
+		dst = s.out
+	}
+	if s.Writer == nil {
+		s.Writer = bufio.NewWriter(dst)
+	} else {
+		s.Writer.Reset(dst)
+	}
+	s.track, s.col = s.width > 0, 0
+	s.stack, s.stats, s.flat = s.stack[:0], Statistics{}, 0
+	s.comment, s.ntok, s.ndoc = "", 0, 0
+	s.prefix = ""
+	s.trailing = nil
+	s.newline = !s.noNewline
+	if s.smartNewline {
+		if tty, ok := isTerminal(w); ok {
+			s.newline = tty
+		}
+	}
+	if s.timeout > 0 {
+		s.deadline = time.Now().Add(s.timeout)
+	}
+	s.colors = nil
+	if s.coloring(w) {
+		s.colors = s.config.colors()
+	}
+	if s.bom {
+		s.WriteString(bom)
+	}
 }
 
 func (s *state) any() error {
-	t, err := s.Token()
+	t, err := s.token()
 	if err != nil {
 		return err
 	}
 	d, ok := t.(json.Delim)
+	if !ok && s.requireContainer && s.depth() == 0 {
+		return ErrScalarRoot
+	}
 	if !ok {
+		if s.top() == object {
+			s.space()
+		}
+		s.stats.Scalars++
+		if s.valueMapper != nil {
+			t = s.valueMapper(s.path(), t)
+			if !isScalar(t) {
+				err = fmt.Errorf("jsonaux: value mapper returned %T", t)
+				return &PathError{Path: s.path(), Err: err}
+			}
+		}
+		if n, ok := t.(json.Number); ok && s.canonical {
+			t, err = canonicalNumber(n)
+			if err != nil {
+				return &PathError{Path: s.path(), Err: err}
+			}
+		}
 		s.scalar(t)
+		if s.pathComments && !s.min && s.flat == 0 && s.depth() > 0 {
+			s.comment = s.path()
+		}
+		if s.keep && !s.min && s.flat == 0 && s.depth() > 0 {
+			s.keepComment()
+		}
 		return nil
 	}
 	return s.composite(d)
 }
 
-func (s *state) composite(d json.Delim) (err error) {
+// isScalar reports whether t is a scalar token, as produced by json.Decoder
+// with UseNumber set.
+func isScalar(t json.Token) bool {
+	switch t.(type) {
+	case nil, bool, string, json.Number:
+		return true
+	}
+	return false
+}
+
+func (s *state) composite(d json.Delim) error {
+	switch {
+	case d == '{' && s.sortKeys && (s.sortDepth <= 0 || s.depth() < s.sortDepth):
+		return s.sortedObject()
+	case d == '[' && s.sortBy != "":
+		return s.sortedArray()
+	case d == '{' && s.dupKeys == DuplicateKeysKeepLast:
+		return s.lastMembers()
+	}
+	return s.layout(d)
+}
+
+// layout chooses the layout of the remainder of a composite.
+func (s *state) layout(d json.Delim) error {
+	if s.width > 0 && s.flat == 0 && !s.min {
+		return s.fitted(d)
+	}
+	return s.expanded(d)
+}
+
+// expanded lays out the remainder of a composite over multiple lines.
+func (s *state) expanded(d json.Delim) error {
+	if d == '[' && s.compact && s.flat == 0 {
+		return s.compactArray()
+	}
+	return s.stream(d)
+}
+
+// stream formats the remainder of a composite as its tokens are read.
+func (s *state) stream(d json.Delim) (err error) {
 	switch d {
 	case '{':
 		err = s.object()
@@ -56,118 +324,318 @@ func (s *state) composite(d json.Delim) (err error) {
 	}
 	if err == nil {
 		// this will be '}' or ']'
-		_, err = s.Token()
+		_, err = s.token()
 	}
 	return err
 }
 
+// replay formats the remainder of the composite d, whose opening delimiter
+// has already been consumed, from tokens buffered in memory.
+func (s *state) replay(d *Document) error {
+	src := s.tokenSource
+	defer func() { s.tokenSource = src }()
+	s.tokenSource = &replay{d.tokens(nil)[1:]}
+	return s.layout(d.Token.(json.Delim))
+}
+
 func (s *state) object() error {
 	s.push(object)
 	defer s.pop()
-	if s.next() == object {
-		s.indent()
-	}
-	s.WriteByte('{')
-	s.space()
+	s.stats.Objects++
+	s.stats.depth(s.depth())
+	s.open('{')
 
 	first := true
 	for s.More() {
-		if !first {
-			s.comma()
-		}
-		err := s.string()
+		k, ok, err := s.key()
 		if err != nil {
 			return err
 		}
+		if !ok {
+			err = s.skip()
+			if err != nil {
+				return err
+			}
+			continue
+		}
 
+		s.sep(first)
+		s.name(k)
 		s.colon()
 		err = s.any()
 		if err != nil {
 			return err
 		}
-
-		s.indent()
 		first = false
 	}
-	return s.WriteByte('}')
+	return s.close('}', first)
 }
 
 func (s *state) array() error {
 	s.push(array)
 	defer s.pop()
-	if s.next() == object {
-		s.indent()
-	}
-	s.WriteByte('[')
-	s.space()
+	s.stats.Arrays++
+	s.stats.depth(s.depth())
+	s.open('[')
 	first := true
 	for s.More() {
-		if !first {
-			s.comma()
-		}
+		s.sep(first)
+		s.elem()
 		err := s.any()
+		if err == nil && s.depth() == 1 {
+			err = s.progress()
+		}
 		if err != nil {
 			return err
 		}
-		s.indent()
 		first = false
 	}
-	return s.WriteByte(']')
+	return s.close(']', first)
 }
 
-func (s *state) string() error {
-	t, err := s.Token()
+// key reads the key of the next object member, reporting the key to be
+// emitted, and whether the member should be emitted at all.
+func (s *state) key() (string, bool, error) {
+	s.between()
+	t, err := s.token()
+	if err != nil {
+		return "", false, err
+	}
+	k := t.(string)
+	err = s.checkKey(k)
+	if err != nil {
+		return "", false, err
+	}
+	out := k
+	if s.keyMapper != nil {
+		out = s.keyMapper(s.path(), k)
+	}
+	s.member(k)
+	if s.keyMapper != nil && out == "" {
+		return "", false, nil
+	}
+	ok, err := s.checkDuplicate(out)
+	if !ok {
+		return "", false, err
+	}
+	s.stats.Members++
+	return out, true, nil
+}
+
+// skip discards the next value.
+func (s *state) skip() error {
+	t, err := s.token()
 	if err != nil {
 		return err
 	}
-	s.scalar(t)
-	return nil
+	return s.skipRest(t)
 }
 
+// skipRest discards the remainder of the value beginning with t.
+func (s *state) skipRest(t json.Token) error {
+	depth := 0
+	for {
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		t, err = s.token()
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// token reads the next token, annotating errors detected by this package
+// with the current path.
+func (s *state) token() (json.Token, error) {
+	err := s.check()
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.Token()
+	if err == ErrInvalidUTF8 {
+		err = &PathError{Path: s.path(), Err: err}
+	}
+	return t, err
+}
+
+// path returns the JSON Pointer of the value currently being read, within
+// the whole input.
+func (s *state) path() string { return s.prefix + s.stack.path() }
+
+// checkedSource reads from the source of s through token, so that the
+// values buffered by readRest are checked as they are read.
+type checkedSource struct{ s *state }
+
+func (c checkedSource) Token() (json.Token, error) { return c.s.token() }
+func (c checkedSource) More() bool                 { return c.s.More() }
+
 func (s *state) scalar(t json.Token) {
 	out, ok := t.(string)
 	if ok {
-		buf, _ := json.Marshal(out)
-		s.Write(buf)
+		if s.unquote && isNumber(out) {
+			s.literal(styleNumber, out)
+			return
+		}
+		if s.foldSpace {
+			out = foldSpace(out)
+		}
+		s.str(styleString, out)
 		return
 	}
+	st := styleLiteral
 	switch t {
 	case nil:
-		out = "null"
+		out = or(s.literals.Null, "null")
 	case true:
-		out = "true"
+		out = or(s.literals.True, "true")
 	case false:
-		out = "false"
+		out = or(s.literals.False, "false")
 	default:
-		out = string(t.(json.Number))
+		n := t.(json.Number)
+		if s.quoteBig && s.bigInt(n) {
+			s.str(styleString, string(n))
+			return
+		}
+		st, out = styleNumber, string(n)
 	}
+	s.literal(st, out)
+}
+
+// literal writes out verbatim, in the given style.
+func (s *state) literal(st style, out string) {
+	s.paint(st)
 	s.WriteString(out)
+	s.unpaint(st)
 }
 
-func (s *state) comma() { s.punc(',') }
-func (s *state) colon() { s.punc(':') }
+// str writes str as a string literal, in the given style.
+func (s *state) str(st style, str string) {
+	s.paint(st)
+	s.quote(str)
+	s.unpaint(st)
+}
+
+// name writes k as an object key.
+func (s *state) name(k string) {
+	if s.unquotedKeys && isIdentifier(k) {
+		s.literal(styleKey, k)
+		return
+	}
+	s.str(styleKey, k)
+}
 
-func (s *state) punc(b byte) {
-	s.WriteByte(b)
-	s.space()
+// isIdentifier reports whether k is an ASCII identifier, in the sense of
+// ECMAScript.
+func isIdentifier(k string) bool {
+	for i := 0; i < len(k); i++ {
+		b := k[i]
+		switch {
+		case b == '_' || b == '$' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z':
+		case '0' <= b && b <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return k != ""
 }
 
+func (s *state) open(b byte) {
+	if s.next() == object {
+		if s.commas == CommaPrefix && s.flat == 0 {
+			// in comma-prefix style, expanded member values
+			// begin a line of their own, following the colon
+			// and a space as ever
+			s.space()
+			s.indent(s.depth() - 1)
+		} else {
+			s.space()
+		}
+	}
+	s.punc(b)
+}
+
+// sep precedes each member or element of the current composite.
+func (s *state) sep(first bool) {
+	switch {
+	case s.flat > 0:
+		if !first {
+			s.punc(',')
+			s.space()
+		}
+	case s.commas == CommaSuffix:
+		if !first {
+			s.punc(',')
+			s.blankLine()
+		}
+		s.indent(s.depth())
+	default:
+		if !first {
+			s.blankLine()
+			s.indent(s.depth() - 1)
+			s.punc(',')
+		}
+		s.space()
+	}
+}
+
+func (s *state) close(b byte, empty bool) error {
+	switch {
+	case s.flat > 0:
+	case empty && s.commas == CommaPrefix:
+		// in comma-prefix style, empty composites are written as { } and [ ]
+		s.space()
+	case !empty:
+		if s.trailingComma && s.commas == CommaSuffix && !s.min {
+			s.punc(',')
+		}
+		s.indent(s.depth() - 1)
+	}
+	return s.punc(b)
+}
+
+// punc writes a single punctuation character.
+func (s *state) punc(b byte) error {
+	s.paint(stylePunct)
+	err := s.WriteByte(b)
+	s.unpaint(stylePunct)
+	return err
+}
+
+func (s *state) colon() { s.punc(':') }
+
 func (s *state) space() {
 	if !s.min {
 		s.WriteByte(' ')
 	}
 }
 
-func (s *state) indent() {
+// indent begins a new line, indented n levels beyond the initial depth.
+func (s *state) indent(n int) {
 	if !s.min {
+		s.flushComment()
 		s.WriteByte('\n')
-		n := s.depth()
-		for i := 1; i < n; i++ {
-			s.WriteString("  ")
+		for i := -s.initDepth; i < n; i++ {
+			s.WriteString(s.indentUnit)
 		}
 	}
 }
 
+// blankLine begins an empty line between top-level members, if configured.
+func (s *state) blankLine() {
+	if s.blankTop && !s.min && s.depth() == 1 {
+		s.flushComment()
+		s.WriteByte('\n')
+	}
+}
+
 type doctype uint8
 
 const (
@@ -176,18 +644,69 @@ const (
 	object
 )
 
-type stack []doctype
+// frame tracks the position within a single composite.
+type frame struct {
+	doctype
+	key string // key of the current object member
+	n   int    // members or elements begun so far
+	cur bool   // whether a current member or element is being read
+
+	keys map[string]struct{} // keys seen, if duplicates are checked
+}
+
+type stack []frame
 
 func (s stack) get(i int) doctype {
 	n := len(s)
 	if i >= n {
 		return none
 	}
-	return s[n-i-1]
+	return s[n-i-1].doctype
 }
 
 func (s stack) depth() int      { return len(s) }
 func (s stack) top() doctype    { return s.get(0) }
 func (s stack) next() doctype   { return s.get(1) }
-func (s *stack) push(t doctype) { *s = append(*s, t) }
+func (s *stack) push(t doctype) { *s = append(*s, frame{doctype: t}) }
 func (s *stack) pop()           { *s = (*s)[:len(*s)-1] }
+
+// elem begins the next array element.
+func (s stack) elem() {
+	f := &s[len(s)-1]
+	f.n++
+	f.cur = true
+}
+
+// current reports whether the innermost composite has a member or element
+// being read, rather than awaiting the next one.
+func (s stack) current() bool { return s[len(s)-1].cur }
+
+// between marks the current object as awaiting its next key.
+func (s stack) between() { s[len(s)-1].cur = false }
+
+// member begins the object member with the given key.
+func (s stack) member(key string) {
+	f := &s[len(s)-1]
+	f.key = key
+	f.n++
+	f.cur = true
+}
+
+// path returns the JSON Pointer of the value currently being read.
+func (s stack) path() string {
+	var buf []byte
+	for _, f := range s {
+		if !f.cur {
+			break
+		}
+		buf = append(buf, '/')
+		if f.doctype == array {
+			buf = strconv.AppendInt(buf, int64(f.n-1), 10)
+		} else {
+			buf = append(buf, pointerEscaper.Replace(f.key)...)
+		}
+	}
+	return string(buf)
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
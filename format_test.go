@@ -0,0 +1,119 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// formatString returns the output of Format for in, failing t on error.
+func formatString(t *testing.T, in string, opts ...Option) string {
+	t.Helper()
+	var b strings.Builder
+	err := Format(&b, strings.NewReader(in), opts...)
+	if err != nil {
+		t.Fatalf("Format(%q): %v", in, err)
+	}
+	return b.String()
+}
+
+func TestFormatEmptyComposites(t *testing.T) {
+	tests := []struct {
+		in   string
+		opts []Option
+		want string
+	}{
+		{`[]`, nil, "[ ]\n"},
+		{`{}`, nil, "{ }\n"},
+		{`[[],{}]`, nil, "[ [ ]\n, { }\n]\n"},
+		{`[]`, []Option{WithMinify(true)}, "[]\n"},
+		{`{"a":[]}`, []Option{WithCommaStyle(CommaSuffix)}, "{\n  \"a\": []\n}\n"},
+		{`{"a":{}}`, []Option{WithLineWidth(80)}, "{\"a\": {}}\n"},
+	}
+	for _, tt := range tests {
+		if got := formatString(t, tt.in, tt.opts...); got != tt.want {
+			t.Errorf("Format(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatIgnoresWhitespace(t *testing.T) {
+	const min = `{"a":[1,2,{"b":"  tab\there  "}],"c":{},"d":[],"e":"line\r\nbreak"}`
+	inputs := []struct{ name, in string }{
+		{"minified", "{\"a\":[1,2,{\"b\":\"  tab\\there  \"}],\"c\":{},\"d\":[],\"e\":\"line\\r\\nbreak\"}"},
+		{"tabs", "{\n\t\"a\": [\n\t\t1,\n\t\t2,\n\t\t{\"b\": \"  tab\\there  \"}\n\t],\n\t\"c\": {},\n\t\"d\": [],\n\t\"e\": \"line\\r\\nbreak\"\n}\n"},
+		{"crlf and spaces", "{\r\n  \"a\" : [ 1 , 2 , { \"b\" : \"  tab\\there  \" } ] ,\r\n  \"c\" : { } ,\r\n  \"d\" : [ ] ,\r\n  \"e\" : \"line\\r\\nbreak\"\r\n}\r\n"},
+		{"odd spacing", "  \t{ \"a\"\n:\n[\n1\n,\n2,\r{\t\"b\"\t:\t\"  tab\\there  \"\t}\t]\n,\"c\"\n:{\n\n}, \"d\":[\r\n],\"e\":\"line\\r\\nbreak\"}  \n\n"},
+		{"tabs between tokens", "{\"a\":[1,\t2,\t{\"b\":\"  tab\\there  \"}\t],\t\"c\":{\t},\t\"d\":[\t],\t\"e\":\"line\\r\\nbreak\"}\t"},
+	}
+	opts := [][]Option{
+		nil,
+		{WithMinify(true)},
+		{WithCommaStyle(CommaSuffix)},
+		{WithIndent("\t")},
+		{WithLineWidth(40)},
+		{WithDecoder(func(*json.Decoder) {})},
+	}
+	for _, o := range opts {
+		want := formatString(t, min, o...)
+		for _, tt := range inputs {
+			if got := formatString(t, tt.in, o...); got != want {
+				t.Errorf("%s: Format = %q, want %q", tt.name, got, want)
+			}
+		}
+	}
+	if got := formatString(t, inputs[1].in, WithMinify(true)); got != min+"\n" {
+		t.Errorf("minified = %q, want %q", got, min+"\n")
+	}
+}
+
+func TestFormatKeepsMemberOrder(t *testing.T) {
+	const in = `{"z":1,"a":2,"m":3}`
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"minified", []Option{WithMinify(true)}},
+		{"line width", []Option{WithLineWidth(80)}},
+		{"keep last duplicate", []Option{WithDuplicateKeys(DuplicateKeysKeepLast)}},
+		{"sorting off", []Option{WithSortKeys(false)}},
+	}
+	for _, tt := range tests {
+		got := formatString(t, in, tt.opts...)
+		z, a, m := strings.Index(got, `"z"`), strings.Index(got, `"a"`), strings.Index(got, `"m"`)
+		if z < 0 || !(z < a && a < m) {
+			t.Errorf("%s: Format = %q, want keys in order z, a, m", tt.name, got)
+		}
+	}
+}
+
+func TestFormatTopLevelScalars(t *testing.T) {
+	scalars := []string{`null`, `true`, `false`, `42`, `"x"`, `1.5`}
+	tests := []struct {
+		name    string
+		opts    []Option
+		newline string
+	}{
+		{"default", nil, "\n"},
+		{"minified", []Option{WithMinify(true)}, "\n"},
+		{"no newline", []Option{WithTrailingNewline(false)}, ""},
+		{"minified, no newline", []Option{WithMinify(true), WithTrailingNewline(false)}, ""},
+		{"comma suffix", []Option{WithCommaStyle(CommaSuffix)}, "\n"},
+		{"indent", []Option{WithIndent("\t")}, "\n"},
+		{"initial depth", []Option{WithInitialDepth(2)}, "\n"},
+		{"line width", []Option{WithLineWidth(1)}, "\n"},
+		{"compact arrays", []Option{WithCompactArraysOfScalars(true)}, "\n"},
+		{"path comments", []Option{WithPathComments(true)}, "\n"},
+		{"trailing comma", []Option{WithCommaStyle(CommaSuffix), WithTrailingComma(true)}, "\n"},
+		{"blank lines", []Option{WithBlankLineBetweenTopLevel(true)}, "\n"},
+	}
+	for _, tt := range tests {
+		for _, in := range scalars {
+			want := in + tt.newline
+			if got := formatString(t, " "+in+" ", tt.opts...); got != want {
+				t.Errorf("%s: Format(%s) = %q, want %q", tt.name, in, got, want)
+			}
+		}
+	}
+}
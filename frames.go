@@ -0,0 +1,83 @@
+package jsonaux
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+// FormatFrames reads a sequence of length-prefixed frames from r, each being
+// a 4-byte big-endian length followed by that many bytes holding a single
+// value, and writes each value to w, formatted independently. Successive
+// values are separated as set by WithDocumentSeparator. Nothing beyond the
+// current frame is read from r. A frame holding anything other than exactly
+// one value is an error, as is one cut short by the end of the input.
+//
+// When an error occurs, the output for the preceding frames is complete, but
+// that for the offending frame may be partial, as each frame is formatted as
+// it is read. In particular, a frame holding a value followed by trailing
+// data has the value written before ErrTrailingData is returned.
+func FormatFrames(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	s := newState(w, nil, c)
+	return s.flushed(s.frames(r))
+}
+
+// frames formats each frame read from r.
+func (s *state) frames(r io.Reader) error {
+	var hdr [4]byte
+	for n := 0; ; n++ {
+		_, err := io.ReadFull(r, hdr[:])
+		if err == io.EOF && n == 0 {
+			return nil
+		}
+		if err == io.EOF {
+			return s.end()
+		}
+		if err != nil {
+			return err
+		}
+		if s.limit(n) {
+			err := s.end()
+			if err != nil {
+				return err
+			}
+			return ErrMaxDocuments
+		}
+		if n > 0 {
+			s.flushComment()
+			s.WriteString(s.docSep)
+		}
+		err = s.frame(&io.LimitedReader{R: r, N: int64(binary.BigEndian.Uint32(hdr[:]))})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// frame formats the single value held by r, which must be fully consumed.
+func (s *state) frame(r *io.LimitedReader) error {
+	dec := newDecoder(r, s.config)
+	s.tokenSource = dec
+	ntok := s.ntok
+	err := s.any()
+	if err == io.EOF && s.ntok-ntok == 1 && r.N == 0 {
+		return ErrEmptyInput
+	}
+	if err == nil {
+		_, err = dec.Token()
+		if err == nil {
+			return ErrTrailingData
+		}
+		if err == io.EOF && r.N == 0 {
+			err = nil
+		}
+	}
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return err
+	}
+	return s.progress()
+}
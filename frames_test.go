@@ -0,0 +1,77 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// frames returns the length-prefixed frames holding docs.
+func frames(docs ...string) *bytes.Buffer {
+	var b bytes.Buffer
+	for _, d := range docs {
+		binary.Write(&b, binary.BigEndian, uint32(len(d)))
+		b.WriteString(d)
+	}
+	return &b
+}
+
+func TestFormatFrames(t *testing.T) {
+	tests := []struct {
+		docs []string
+		opts []Option
+		want string
+	}{
+		{nil, nil, ""},
+		{[]string{"1", "[1]"}, nil, "1\n[ 1\n]\n"},
+		{[]string{`{"a":1}`, " 2 ", "[]"}, []Option{WithMinify(true)}, "{\"a\":1}\n2\n[]\n"},
+		{[]string{"1", "2", "3"}, []Option{WithDocumentSeparator("\n---\n")}, "1\n---\n2\n---\n3\n"},
+		{[]string{"1", "2"}, []Option{WithDocumentSeparator(" "), WithTrailingNewline(false)}, "1 2"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		err := FormatFrames(&b, frames(tt.docs...), tt.opts...)
+		if err != nil {
+			t.Errorf("FormatFrames(%q): %v", tt.docs, err)
+			continue
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("FormatFrames(%q) = %q, want %q", tt.docs, got, tt.want)
+		}
+	}
+}
+
+func TestFormatFramesErrors(t *testing.T) {
+	tests := []struct {
+		docs []string
+		want error
+		out  string
+	}{
+		{[]string{""}, ErrEmptyInput, ""},
+		{[]string{"1", "  "}, ErrEmptyInput, "1\n"},
+		// the value of a frame with trailing data is written
+		{[]string{"1", "2 3"}, ErrTrailingData, "1\n2"},
+	}
+	for _, tt := range tests {
+		var b bytes.Buffer
+		err := FormatFrames(&b, frames(tt.docs...))
+		if !errors.Is(err, tt.want) {
+			t.Errorf("FormatFrames(%q) = %v, want %v", tt.docs, err, tt.want)
+		}
+		if got := b.String(); got != tt.out {
+			t.Errorf("FormatFrames(%q) wrote %q, want %q", tt.docs, got, tt.out)
+		}
+	}
+
+	// frames cut short, within the value or the header
+	b := frames("[1,2]")
+	if err := FormatFrames(new(bytes.Buffer), bytes.NewReader(b.Bytes()[:b.Len()-1])); err == nil {
+		t.Error("FormatFrames of a truncated frame succeeded")
+	}
+	b = frames("1")
+	b.WriteByte(0)
+	if err := FormatFrames(new(bytes.Buffer), b); err == nil {
+		t.Error("FormatFrames of a truncated header succeeded")
+	}
+}
@@ -0,0 +1,336 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+// Stats describes the content of the input, as reported by Inspect.
+type Stats struct {
+	Statistics
+
+	Documents     int   // number of top-level values
+	Strings       int   // number of string values, excluding object keys
+	Numbers       int   // number of numbers
+	Bools         int   // number of true and false values
+	Nulls         int   // number of null values
+	LongestString int   // bytes in the longest string or object key, decoded
+	LargestArray  int   // most elements in any one array
+	LargestObject int   // most members in any one object
+	Bytes         int64 // size of the input
+
+	Shape *Shape // outline of the values, if requested by WithShape
+}
+
+// WithShape causes Inspect to also infer the Shape of the values read. The
+// shape grows with the number of distinct keys at each place, so objects
+// used as maps with many keys make for a large shape.
+func WithShape(shape bool) Option {
+	return func(c *config) { c.shape = shape }
+}
+
+// Inspect reads all values up to the end of r, and reports statistics about
+// them, without formatting them or holding them in memory. It is meant for
+// surveying large or unknown input before deciding how to process it. On
+// error, the statistics cover the input read before the error occurred.
+func Inspect(r io.Reader, opts ...Option) (Stats, error) {
+	c := newConfig(opts)
+
+	cr := &countReader{r: r}
+	in := &inspector{src: newDecoder(cr, c)}
+	if c.shape {
+		in.st.Shape = new(Shape)
+	}
+	err := in.documents()
+	in.st.Bytes = cr.n
+	in.st.Shape.finish()
+	return in.st, err
+}
+
+// inspector gathers Stats for the values read from src.
+type inspector struct {
+	src tokenSource
+	st  Stats
+}
+
+func (in *inspector) documents() error {
+	for {
+		t, err := in.src.Token()
+		switch {
+		case err == io.EOF && in.st.Documents == 0:
+			return ErrEmptyInput
+		case err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		}
+		in.st.Documents++
+		err = in.value(t, in.st.Shape, 0)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// value gathers statistics for the value beginning with t, at the given
+// depth, merging it into sh.
+func (in *inspector) value(t json.Token, sh *Shape, depth int) error {
+	switch t := t.(type) {
+	case json.Delim:
+		if t == '{' {
+			return in.object(sh, depth+1)
+		}
+		return in.array(sh, depth+1)
+	case string:
+		in.st.Strings++
+		in.str(t)
+		sh.add(ShapeString)
+	case bool:
+		in.st.Bools++
+		sh.add(ShapeBool)
+	case nil:
+		in.st.Nulls++
+		sh.add(ShapeNull)
+	default:
+		in.st.Numbers++
+		sh.add(ShapeNumber)
+	}
+	in.st.Scalars++
+	return nil
+}
+
+func (in *inspector) object(sh *Shape, depth int) error {
+	in.st.Objects++
+	in.st.depth(depth)
+	sh.add(ShapeObject)
+	n := 0
+	for ; in.src.More(); n++ {
+		k, err := in.token()
+		if err != nil {
+			return err
+		}
+		key := k.(string)
+		in.str(key)
+		t, err := in.token()
+		if err != nil {
+			return err
+		}
+		err = in.value(t, sh.field(key), depth)
+		if err != nil {
+			return err
+		}
+	}
+	in.st.Members += n
+	in.st.LargestObject = max(in.st.LargestObject, n)
+	// this will be '}'
+	_, err := in.token()
+	return err
+}
+
+func (in *inspector) array(sh *Shape, depth int) error {
+	in.st.Arrays++
+	in.st.depth(depth)
+	sh.add(ShapeArray)
+	n := 0
+	for ; in.src.More(); n++ {
+		t, err := in.token()
+		if err != nil {
+			return err
+		}
+		err = in.value(t, sh.elements(), depth)
+		if err != nil {
+			return err
+		}
+	}
+	in.st.LargestArray = max(in.st.LargestArray, n)
+	// this will be ']'
+	_, err := in.token()
+	return err
+}
+
+// token reads a token within a composite, for which the input may not end.
+func (in *inspector) token() (json.Token, error) {
+	t, err := in.src.Token()
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return t, err
+}
+
+func (in *inspector) str(s string) {
+	in.st.LongestString = max(in.st.LongestString, len(s))
+}
+
+// countReader counts the bytes read from r.
+type countReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ShapeType is a set of JSON types.
+type ShapeType uint8
+
+// The types within a ShapeType, named by String as in JSON Schema.
+const (
+	ShapeObject ShapeType = 1 << iota
+	ShapeArray
+	ShapeString
+	ShapeNumber
+	ShapeBool
+	ShapeNull
+)
+
+var shapeTypeNames = []string{"object", "array", "string", "number", "boolean", "null"}
+
+// String returns the names of the types in t, separated by " | ".
+func (t ShapeType) String() string {
+	var names []string
+	for i, name := range shapeTypeNames {
+		if t&(1<<i) != 0 {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, " | ")
+}
+
+// Shape outlines the values found at one place within the input, such as the
+// top level, the elements of an array, or the members of objects having a
+// given key, merging them into a sketch of their schema.
+type Shape struct {
+	Count    int          // number of values seen
+	Types    ShapeType    // types of the values seen
+	Fields   []ShapeField // members of the objects seen, in order of first appearance
+	Elements *Shape       // elements of the arrays seen, if any had elements
+
+	objects int            // number of objects seen
+	fields  map[string]int // index of each key within Fields
+}
+
+// ShapeField outlines the members having one key within the objects at a
+// place.
+type ShapeField struct {
+	Key      string
+	Optional bool // whether any of the objects lacked the key
+	Shape    *Shape
+
+	present int // number of objects having the key
+	last    int // the object last having the key, counting from 1
+}
+
+// String returns the shape in a compact notation, such as
+//
+//	{"id": number, "tags"?: [string], "parent": {"id": number} | null}
+//
+// where a field marked ? is optional, and [] is an array seen only empty.
+func (sh *Shape) String() string {
+	var b strings.Builder
+	sh.write(&b)
+	return b.String()
+}
+
+func (sh *Shape) write(b *strings.Builder) {
+	if sh.Types == 0 {
+		b.WriteString("never")
+		return
+	}
+	for i, name := range shapeTypeNames {
+		t := ShapeType(1 << i)
+		if sh.Types&t == 0 {
+			continue
+		}
+		if sh.Types&(t-1) != 0 {
+			b.WriteString(" | ")
+		}
+		switch t {
+		case ShapeObject:
+			b.WriteByte('{')
+			for j, f := range sh.Fields {
+				if j > 0 {
+					b.WriteString(", ")
+				}
+				b.Write(appendString(nil, f.Key, &config{}))
+				if f.Optional {
+					b.WriteByte('?')
+				}
+				b.WriteString(": ")
+				f.Shape.write(b)
+			}
+			b.WriteByte('}')
+		case ShapeArray:
+			b.WriteByte('[')
+			if sh.Elements != nil {
+				sh.Elements.write(b)
+			}
+			b.WriteByte(']')
+		default:
+			b.WriteString(name)
+		}
+	}
+}
+
+// add records a value of type t, unless sh is nil.
+func (sh *Shape) add(t ShapeType) {
+	if sh == nil {
+		return
+	}
+	sh.Count++
+	sh.Types |= t
+	if t == ShapeObject {
+		sh.objects++
+	}
+}
+
+// field returns the shape of the members with the given key, or nil if sh is
+// nil.
+func (sh *Shape) field(key string) *Shape {
+	if sh == nil {
+		return nil
+	}
+	i, ok := sh.fields[key]
+	if !ok {
+		if sh.fields == nil {
+			sh.fields = make(map[string]int)
+		}
+		i = len(sh.Fields)
+		sh.fields[key] = i
+		sh.Fields = append(sh.Fields, ShapeField{Key: key, Shape: new(Shape)})
+	}
+	f := &sh.Fields[i]
+	if f.last != sh.objects {
+		f.last = sh.objects
+		f.present++
+	}
+	return f.Shape
+}
+
+// elements returns the shape of the array elements, or nil if sh is nil.
+func (sh *Shape) elements() *Shape {
+	if sh == nil {
+		return nil
+	}
+	if sh.Elements == nil {
+		sh.Elements = new(Shape)
+	}
+	return sh.Elements
+}
+
+// finish marks the optional fields within sh, unless it is nil.
+func (sh *Shape) finish() {
+	if sh == nil {
+		return
+	}
+	for i := range sh.Fields {
+		f := &sh.Fields[i]
+		f.Optional = f.present < sh.objects
+		f.Shape.finish()
+	}
+	sh.Elements.finish()
+	sh.fields = nil
+}
@@ -0,0 +1,133 @@
+package jsonaux
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Stats // the Bytes are those of in
+	}{
+		{`{"id":1,"tags":["a","bcd"],"p":null}`, Stats{
+			Statistics: Statistics{MaxDepth: 2, Objects: 1, Arrays: 1, Members: 3, Scalars: 4},
+			Documents:  1, Strings: 2, Numbers: 1, Nulls: 1,
+			LongestString: 4, LargestArray: 2, LargestObject: 3,
+		}},
+		{"1 \"xy\" true\n[] {}\n", Stats{
+			Statistics: Statistics{MaxDepth: 1, Objects: 1, Arrays: 1, Scalars: 3},
+			Documents:  5, Strings: 1, Numbers: 1, Bools: 1,
+			LongestString: 2,
+		}},
+		{`[[[{"k":false}]],[]]`, Stats{
+			Statistics: Statistics{MaxDepth: 4, Objects: 1, Arrays: 4, Members: 1, Scalars: 1},
+			Documents:  1, Bools: 1,
+			LongestString: 1, LargestArray: 2, LargestObject: 1,
+		}},
+		// strings are measured decoded, and keys are counted as often as they occur
+		{`{"é\n":"é","a":1,"a":2}`, Stats{
+			Statistics: Statistics{MaxDepth: 1, Objects: 1, Members: 3, Scalars: 3},
+			Documents:  1, Strings: 1, Numbers: 2,
+			LongestString: 3, LargestObject: 3,
+		}},
+		{`-0.5e1`, Stats{
+			Statistics: Statistics{Scalars: 1},
+			Documents:  1, Numbers: 1,
+		}},
+	}
+	for _, tt := range tests {
+		st, err := Inspect(strings.NewReader(tt.in))
+		tt.want.Bytes = int64(len(tt.in))
+		if err != nil || st != tt.want {
+			t.Errorf("Inspect(%q) = %+v, %v, want %+v", tt.in, st, err, tt.want)
+		}
+	}
+}
+
+func TestInspectErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Stats // the statistics gathered before the error
+	}{
+		{`{"a":[1,true,`, Stats{
+			Statistics: Statistics{MaxDepth: 2, Objects: 1, Arrays: 1, Scalars: 2},
+			Documents:  1, Numbers: 1, Bools: 1, LongestString: 1,
+		}},
+		{`[] {"ab":`, Stats{
+			Statistics: Statistics{MaxDepth: 1, Objects: 1, Arrays: 1},
+			Documents:  2, LongestString: 2,
+		}},
+		{`"x" }`, Stats{
+			Statistics: Statistics{Scalars: 1},
+			Documents:  1, Strings: 1, LongestString: 1,
+		}},
+	}
+	for _, tt := range tests {
+		st, err := Inspect(strings.NewReader(tt.in))
+		st.Bytes = 0
+		if err == nil || st != tt.want {
+			t.Errorf("Inspect(%q) = %+v, %v, want %+v and an error", tt.in, st, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", " \n\t"} {
+		if _, err := Inspect(strings.NewReader(in)); !errors.Is(err, ErrEmptyInput) {
+			t.Errorf("Inspect(%q) = %v, want %v", in, err, ErrEmptyInput)
+		}
+	}
+}
+
+func TestShape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`{"id":1,"tags":["a"],"p":null} {"id":2,"p":{"id":3}}`,
+			`{"id": number, "tags"?: [string], "p": {"id": number} | null}`},
+		{`[{},{"a":true},{"a":false,"b":[]}]`, `[{"a"?: boolean, "b"?: []}]`},
+		{`[[],[1]]`, `[[number]]`},
+		{`[1,"a",null,true]`, `[string | number | boolean | null]`},
+		{`[] 1 "a"`, `[] | string | number`},
+		{`{"x":{"y":1}} {"x":{}}`, `{"x": {"y"?: number}}`},
+		// a recurring key within an object is not optional
+		{`{"a":1,"a":"x"} {"a":null}`, `{"a": string | number | null}`},
+		{`{"a\"bé":1}`, `{"a\"bé": number}`},
+		{`{}`, `{}`},
+	}
+	for _, tt := range tests {
+		st, err := Inspect(strings.NewReader(tt.in), WithShape(true))
+		if err != nil || st.Shape.String() != tt.want {
+			t.Errorf("Inspect(%s) shape = %s, %v, want %s", tt.in, st.Shape, err, tt.want)
+		}
+	}
+
+	st, err := Inspect(strings.NewReader(`1 2 [3,[]]`), WithShape(true))
+	sh := st.Shape
+	if err != nil || sh.Count != 3 || sh.Types != ShapeNumber|ShapeArray || sh.Elements.Count != 2 || sh.Elements.Elements != nil {
+		t.Errorf("Inspect shape = %+v, %v", sh, err)
+	}
+	if st, _ := Inspect(strings.NewReader(`[1]`)); st.Shape != nil {
+		t.Errorf("Inspect without WithShape gave shape %s", st.Shape)
+	}
+	if got := new(Shape).String(); got != "never" {
+		t.Errorf("empty Shape = %s, want never", got)
+	}
+}
+
+func TestShapeType(t *testing.T) {
+	tests := []struct {
+		t    ShapeType
+		want string
+	}{
+		{0, ""},
+		{ShapeObject, "object"},
+		{ShapeNull | ShapeBool, "boolean | null"},
+		{ShapeObject | ShapeArray | ShapeString | ShapeNumber | ShapeBool | ShapeNull, "object | array | string | number | boolean | null"},
+	}
+	for _, tt := range tests {
+		if got := tt.t.String(); got != tt.want {
+			t.Errorf("ShapeType(%d) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
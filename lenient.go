@@ -0,0 +1,299 @@
+package jsonaux
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// LenientReader returns a reader which translates input written in the
+// relaxed syntax of JSONC or JSON5 into standard JSON, as read from r. It
+// accepts line and block comments, commas following the last member or
+// element of a composite, object keys which are unquoted identifiers, and
+// strings delimited by single quotes. Other extensions, such as hexadecimal
+// numbers, are passed through as is, and so rejected when decoded. Input is
+// translated as it is read.
+//
+// Comments are discarded, although the line breaks within them are
+// retained, so that line numbers are unchanged. WithPreserveComments carries
+// some of them into formatted output instead.
+func LenientReader(r io.Reader) io.Reader {
+	return &lenientReader{r: bufio.NewReader(r), json5: true}
+}
+
+// WithLenientInput controls whether input is read through LenientReader,
+// allowing JSONC and JSON5 to be formatted as standard JSON. Comments within
+// the input are discarded, unless preserved by WithPreserveComments.
+func WithLenientInput(enable bool) Option {
+	return func(c *config) { c.lenient = enable }
+}
+
+// WithAllowComments controls whether line and block comments are accepted
+// within the input, and discarded, as by LenientReader, but without also
+// accepting its other extensions. It is independent of the options producing
+// JSON5 output, such as WithUnquotedKeys and WithTrailingComma, and implied
+// by WithLenientInput.
+func WithAllowComments(allow bool) Option {
+	return func(c *config) { c.comments = allow }
+}
+
+// WithPreserveComments controls whether line comments following a scalar on
+// the same line of input are written following it in the output, as with
+// WithPathComments, in whose place they appear; a path comment, if also
+// enabled, precedes them. It implies WithAllowComments. Other comments are
+// discarded, as are those within composites laid out on a single line or
+// buffered in memory, as when sorting keys. The result is JSONC rather than
+// JSON, and is not accepted by standard parsers.
+func WithPreserveComments(enable bool) Option {
+	return func(c *config) { c.keep = enable }
+}
+
+type lenientReader struct {
+	r     *bufio.Reader
+	out   []byte // translated input not yet returned
+	err   error
+	stack []byte // the opening delimiters of the enclosing composites
+	key   bool   // an object key may come next
+	comma bool   // a comma has been read, but not yet written
+	json5 bool   // whether extensions other than comments are translated
+
+	keep  bool          // whether line comments following a token are kept
+	lines []lineComment // the comments kept, not yet taken
+	off   int64         // bytes translated
+	end   int64         // offset following the last token byte translated
+	tail  bool          // whether the line holding that byte continues
+}
+
+// lineComment is a line comment following a token on the same line.
+type lineComment struct {
+	end  int64 // offset following the token, within the translated input
+	text string
+}
+
+func (r *lenientReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.out = r.out[:0]
+		r.err = r.step()
+		r.track()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// step translates the next token, whitespace character, or comment.
+func (r *lenientReader) step() error {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		r.flushComma()
+		return err
+	}
+	switch b {
+	case ' ', '\t', '\r', '\n':
+		r.out = append(r.out, b)
+		return nil
+	case '/':
+		return r.comment()
+	}
+	if !r.json5 {
+		if b == ',' && r.keep {
+			// deferred, so that a comment following it is read before any
+			// token following it
+			r.flushComma()
+			r.comma = true
+			return nil
+		}
+		r.flushComma()
+		if b == '"' {
+			return r.str(b)
+		}
+		r.out = append(r.out, b)
+		return nil
+	}
+	switch b {
+	case ',':
+		// written only once it is known not to be trailing
+		r.flushComma()
+		r.comma = true
+		r.key = len(r.stack) > 0 && r.stack[len(r.stack)-1] == '{'
+		return nil
+	case '}', ']':
+		r.comma = false
+		if len(r.stack) > 0 {
+			r.stack = r.stack[:len(r.stack)-1]
+		}
+		r.out = append(r.out, b)
+		r.key = false
+		return nil
+	}
+	r.flushComma()
+	key := r.key
+	r.key = false
+	switch {
+	case b == '{' || b == '[':
+		r.stack = append(r.stack, b)
+		r.out = append(r.out, b)
+		r.key = b == '{'
+	case b == '"' || b == '\'':
+		return r.str(b)
+	case key && isIdentByte(b, true):
+		return r.ident(b)
+	default:
+		r.out = append(r.out, b)
+	}
+	return nil
+}
+
+// track records the position of the bytes just translated.
+func (r *lenientReader) track() {
+	for _, b := range r.out {
+		r.off++
+		switch b {
+		case '\n':
+			r.tail = false
+		case ' ', '\t', '\r', ',':
+		default:
+			r.end, r.tail = r.off, true
+		}
+	}
+}
+
+// take returns the text of the line comment following the token ending at
+// offset end, if any, discarding any preceding it.
+func (r *lenientReader) take(end int64) string {
+	for len(r.lines) > 0 && r.lines[0].end < end {
+		r.lines = r.lines[1:]
+	}
+	if len(r.lines) == 0 || r.lines[0].end != end {
+		return ""
+	}
+	text := r.lines[0].text
+	r.lines = r.lines[1:]
+	return text
+}
+
+func (r *lenientReader) flushComma() {
+	if r.comma {
+		r.out = append(r.out, ',')
+		r.comma = false
+	}
+}
+
+// comment discards the comment begun by a slash, retaining its line breaks.
+func (r *lenientReader) comment() error {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		r.out = append(r.out, '/')
+		return err
+	}
+	switch b {
+	case '/':
+		var text []byte
+		for {
+			b, err = r.r.ReadByte()
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if err == nil && b != '\n' {
+				if r.keep {
+					text = append(text, b)
+				}
+				continue
+			}
+			if t := strings.TrimSpace(string(text)); r.keep && r.tail && t != "" {
+				r.lines = append(r.lines, lineComment{r.end, t})
+			}
+			if err != nil {
+				return err
+			}
+			r.out = append(r.out, b)
+			return nil
+		}
+	case '*':
+		r.out = append(r.out, ' ')
+		star := false
+		for {
+			b, err = r.r.ReadByte()
+			if err == io.EOF {
+				return io.ErrUnexpectedEOF
+			}
+			if err != nil {
+				return err
+			}
+			if star && b == '/' {
+				return nil
+			}
+			star = b == '*'
+			if b == '\n' {
+				r.out = append(r.out, b)
+			}
+		}
+	}
+	// not a comment, and so invalid
+	r.out = append(r.out, '/', b)
+	return nil
+}
+
+// str translates the string begun by the quote q into a double-quoted one.
+func (r *lenientReader) str(q byte) error {
+	r.out = append(r.out, '"')
+	for {
+		b, err := r.r.ReadByte()
+		if err != nil {
+			return err
+		}
+		switch {
+		case b == q:
+			r.out = append(r.out, '"')
+			return nil
+		case b == '\\':
+			b, err = r.r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if b != '\'' || !r.json5 {
+				r.out = append(r.out, '\\')
+			}
+		case b == '"':
+			r.out = append(r.out, '\\')
+		}
+		r.out = append(r.out, b)
+	}
+}
+
+// ident translates the unquoted key beginning with b into a string.
+func (r *lenientReader) ident(b byte) error {
+	r.out = append(r.out, '"', b)
+	for {
+		b, err := r.r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if !isIdentByte(b, false) {
+			r.r.UnreadByte()
+			break
+		}
+		r.out = append(r.out, b)
+	}
+	r.out = append(r.out, '"')
+	return nil
+}
+
+// isIdentByte reports whether b may appear within an identifier, at its
+// start if first is set. Bytes of multi-byte UTF-8 sequences are accepted,
+// without determining whether the character they encode is a letter.
+func isIdentByte(b byte, first bool) bool {
+	switch {
+	case b == '_' || b == '$' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z':
+		return true
+	case '0' <= b && b <= '9':
+		return !first
+	}
+	return b >= 0x80
+}
@@ -0,0 +1,75 @@
+package jsonaux
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestAllowComments(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		opts []Option
+		want string // the minified output, or empty if an error is expected
+	}{
+		{"line comment", "{\"a\":1, // note\n\"b\":2}", []Option{WithAllowComments(true)}, `{"a":1,"b":2}`},
+		{"block comment", `[1,/* two */2]`, []Option{WithAllowComments(true)}, `[1,2]`},
+		{"slashes in strings", `{"url":"http://x/*y*/"}`, []Option{WithAllowComments(true)}, `{"url":"http://x/*y*/"}`},
+		{"comments disallowed", `[1 /* c */]`, nil, ""},
+		{"trailing comma", `[1,2,]`, []Option{WithAllowComments(true)}, ""},
+		{"unquoted key", `{a:1}`, []Option{WithAllowComments(true)}, ""},
+		{"single quotes", `['a']`, []Option{WithAllowComments(true)}, ""},
+		{"escaped single quote", `["\'"]`, []Option{WithAllowComments(true)}, ""},
+		{"lenient", `{a:'b', /* c */ d:[1,],}`, []Option{WithLenientInput(true)}, `{"a":"b","d":[1]}`},
+		{"lenient without comments", `[1, // c` + "\n]", []Option{WithLenientInput(true), WithAllowComments(false)}, `[1]`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		opts := append([]Option{WithMinify(true), WithTrailingNewline(false)}, tt.opts...)
+		err := Format(&b, strings.NewReader(tt.in), opts...)
+		switch {
+		case tt.want == "" && err == nil:
+			t.Errorf("%s: Format = %q, want an error", tt.name, b.String())
+		case tt.want != "" && (err != nil || b.String() != tt.want):
+			t.Errorf("%s: Format = %q, %v, want %q", tt.name, b.String(), err, tt.want)
+		}
+	}
+}
+
+// TestJSON5Options checks that the options concerning JSON5 combine freely.
+func TestJSON5Options(t *testing.T) {
+	const in = "{\"id\":1, // the id\n\"two words\":[true]}"
+	for _, comments := range []bool{false, true} {
+		for _, unquoted := range []bool{false, true} {
+			for _, trailing := range []bool{false, true} {
+				var b strings.Builder
+				err := Format(&b, strings.NewReader(in),
+					WithCommaStyle(CommaSuffix),
+					WithAllowComments(comments),
+					WithUnquotedKeys(unquoted),
+					WithTrailingComma(trailing))
+				if !comments {
+					if err == nil {
+						t.Errorf("comments disallowed, unquoted %t, trailing %t: Format = %q, want an error", unquoted, trailing, b.String())
+					}
+					continue
+				}
+				id, comma := `"id"`, ""
+				if unquoted {
+					id = "id"
+				}
+				if trailing {
+					comma = ","
+				}
+				want := "{\n  " + id + ": 1,\n  \"two words\": [\n    true" + comma + "\n  ]" + comma + "\n}\n"
+				if err != nil || b.String() != want {
+					t.Errorf("unquoted %t, trailing %t: Format = %q, %v, want %q", unquoted, trailing, b.String(), err, want)
+				}
+			}
+		}
+	}
+	if err := Format(io.Discard, strings.NewReader(in), WithUnquotedKeys(true), WithTrailingComma(true)); err == nil {
+		t.Error("JSON5 output options alone accepted comments")
+	}
+}
@@ -0,0 +1,222 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrKeyTooLong is returned, wrapped in a *PathError, when an object key
+// exceeds the length set by WithMaxKeyLength.
+var ErrKeyTooLong = errors.New("jsonaux: object key too long")
+
+// ErrScalarRoot is returned when a top-level value is required to be an
+// object or array, but is not.
+var ErrScalarRoot = errors.New("jsonaux: top-level value is not an object or array")
+
+// WithRequireContainerRoot controls whether each top-level value must be an
+// object or array, failing with ErrScalarRoot for a top-level scalar such as
+// 42 or null. For functions producing a sequence of documents, such as
+// SplitArray, each document is subject to the requirement. The default is to
+// format scalars as usual.
+func WithRequireContainerRoot(require bool) Option {
+	return func(c *config) { c.requireContainer = require }
+}
+
+// WithMaxKeyLength limits object keys to n bytes, as decoded, failing with
+// ErrKeyTooLong for any longer key. The error's path is that of the object
+// holding the key. A limit of zero or less, the default, means no limit.
+func WithMaxKeyLength(n int) Option {
+	return func(c *config) { c.maxKey = n }
+}
+
+// checkKey enforces the key length limit.
+func (s *state) checkKey(k string) error {
+	if s.maxKey <= 0 || len(k) <= s.maxKey {
+		return nil
+	}
+	err := fmt.Errorf("%w: %q (%d bytes)", ErrKeyTooLong, truncate(k, 32), len(k))
+	return &PathError{Path: s.path(), Err: err}
+}
+
+// truncate shortens str to at most n bytes, without splitting a UTF-8
+// sequence, marking any truncation with an ellipsis.
+func truncate(str string, n int) string {
+	if len(str) <= n {
+		return str
+	}
+	for n > 0 && !utf8.RuneStart(str[n]) {
+		n--
+	}
+	return str[:n] + "..."
+}
+
+// ErrOutputTooLarge is returned when the output would exceed the size set by
+// WithMaxOutputBytes.
+var ErrOutputTooLarge = errors.New("jsonaux: output too large")
+
+// WithMaxOutputBytes limits the output to n bytes, failing with
+// ErrOutputTooLarge once more would be written. Output up to the limit is
+// still written to the destination, so it may hold a truncated document. A
+// limit of zero or less, the default, means no limit.
+func WithMaxOutputBytes(n int64) Option {
+	return func(c *config) { c.maxOutput = n }
+}
+
+// limitWriter writes to w until n bytes remain, then fails.
+type limitWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if l.err != nil {
+		return 0, l.err
+	}
+	var err error
+	if int64(len(p)) > l.n {
+		p = p[:l.n]
+		err = ErrOutputTooLarge
+	}
+	n, werr := l.w.Write(p)
+	l.n -= int64(n)
+	if werr != nil {
+		err = werr
+	}
+	l.err = err
+	return n, err
+}
+
+// ErrTooDeep is returned, wrapped in a *PathError naming the offending
+// value, when objects and arrays are nested more deeply than the depth set by
+// WithMaxDepth.
+var ErrTooDeep = errors.New("jsonaux: nesting too deep")
+
+// ErrTokenTooLarge is returned, wrapped in a *PathError, when a string or
+// number exceeds the size set by WithMaxTokenSize.
+var ErrTokenTooLarge = errors.New("jsonaux: string or number too large")
+
+// ErrInputTooLarge is returned, wrapped in a *PathError, when the input
+// exceeds the size set by WithMaxInputBytes.
+var ErrInputTooLarge = errors.New("jsonaux: input too large")
+
+// WithMaxDepth limits the nesting of objects and arrays to n levels, failing
+// with ErrTooDeep for any composite nested more deeply, so that [[1]] has a
+// depth of two. A limit of zero or less, the default, means no limit.
+func WithMaxDepth(n int) Option {
+	return func(c *config) { c.maxDepth = n }
+}
+
+// WithMaxTokenSize limits each string, including object keys, and each
+// number to n bytes as spelled in the input, excluding any quotes, failing
+// with ErrTokenTooLarge for any larger one before it is read in full. When
+// WithDecoder is given, the size is instead measured once decoded. The
+// error's path is that of the value containing the token. A limit of zero or
+// less, the default, means no limit.
+func WithMaxTokenSize(n int) Option {
+	return func(c *config) { c.maxToken = n }
+}
+
+// WithMaxInputBytes limits the input to n bytes, failing with
+// ErrInputTooLarge once more is read. The error's path is that of the value
+// being read. A limit of zero or less, the default, means no limit.
+func WithMaxInputBytes(n int64) Option {
+	return func(c *config) { c.maxInput = n }
+}
+
+// limitReader reads from r until more than n bytes would be read, then
+// fails.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrInputTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n - 1, ErrInputTooLarge
+	}
+	return n, err
+}
+
+// limitDecoder returns dec, wrapped in a limitedDecoder if c sets any of
+// the limits it enforces or annotates.
+func limitDecoder(dec decoder, c config) decoder {
+	if c.maxDepth > 0 || c.maxToken > 0 || c.maxInput > 0 {
+		dec = &limitedDecoder{decoder: dec, maxDepth: c.maxDepth}
+	}
+	return dec
+}
+
+// limitedDecoder enforces the depth limit upon a decoder, and annotates the
+// errors reported by it for the size limits, tracking the path of each token
+// read in order to report where any limit is exceeded.
+type limitedDecoder struct {
+	decoder
+	stack
+	maxDepth int
+}
+
+func (l *limitedDecoder) Token() (json.Token, error) {
+	t, err := l.decoder.Token()
+	if err != nil {
+		if errors.Is(err, ErrTokenTooLarge) || errors.Is(err, ErrInputTooLarge) {
+			if l.top() == array {
+				l.elem() // the element being read
+			}
+			err = &PathError{Path: l.path(), Err: err}
+		}
+		return nil, err
+	}
+	switch t {
+	case json.Delim('}'), json.Delim(']'):
+		l.pop()
+		l.done()
+		return t, nil
+	}
+	switch l.top() {
+	case object:
+		if !l.current() {
+			l.member(t.(string))
+			return t, nil
+		}
+	case array:
+		l.elem()
+	}
+	switch t {
+	case json.Delim('{'), json.Delim('['):
+		if l.maxDepth > 0 && l.depth() >= l.maxDepth {
+			return nil, &PathError{Path: l.path(), Err: ErrTooDeep}
+		}
+		if t == json.Delim('{') {
+			l.push(object)
+		} else {
+			l.push(array)
+		}
+	default:
+		l.done()
+	}
+	return t, nil
+}
+
+// tokenSize returns the decoded size of a string or number token, or zero
+// for any other.
+func tokenSize(t json.Token) int {
+	switch t := t.(type) {
+	case string:
+		return len(t)
+	case json.Number:
+		return len(t)
+	}
+	return 0
+}
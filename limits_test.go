@@ -0,0 +1,26 @@
+package jsonaux
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMaxKeyLength(t *testing.T) {
+	if got := formatString(t, `{"a":{"abcde":1,"ab":2}}`, WithMaxKeyLength(5), WithMinify(true)); got != "{\"a\":{\"abcde\":1,\"ab\":2}}\n" {
+		t.Errorf("keys within the limit: Format = %q", got)
+	}
+
+	err := Format(io.Discard, strings.NewReader(`{"a":{"abc":1,"abcdef":2}}`), WithMaxKeyLength(5))
+	if !errors.Is(err, ErrKeyTooLong) {
+		t.Fatalf("err = %v, want ErrKeyTooLong", err)
+	}
+	var pe *PathError
+	if !errors.As(err, &pe) || pe.Path != "/a" {
+		t.Errorf("err = %v, want path /a", err)
+	}
+	if !strings.Contains(err.Error(), `"abcdef"`) {
+		t.Errorf("err = %v, want the offending key", err)
+	}
+}
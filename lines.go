@@ -0,0 +1,254 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrNotArray is returned when the top-level input value is required to be an
+// array, but is not.
+var ErrNotArray = errors.New("jsonaux: top-level value is not an array")
+
+// ErrMaxDocuments is returned when input remains after the number of
+// documents set by WithMaxDocuments has been processed. The output for those
+// documents is complete.
+var ErrMaxDocuments = errors.New("jsonaux: document limit reached")
+
+// WithMaxDocuments limits functions handling a sequence of documents, such as
+// SplitArray, JoinLines, and ValidateLines, to the first n documents. If the
+// input holds more, they fail with ErrMaxDocuments, allowing truncation to be
+// distinguished from the end of the input. A limit of zero or less, the
+// default, means no limit.
+func WithMaxDocuments(n int) Option {
+	return func(c *config) { c.maxDocs = n }
+}
+
+// limit reports whether n documents reach the document limit.
+func (c *config) limit(n int) bool { return c.maxDocs > 0 && n >= c.maxDocs }
+
+// SplitArray reads a top-level array from r and writes each of its elements
+// to w on a line of its own, producing newline-delimited JSON. Elements are
+// minified unless overridden by opts. The array is consumed one element at a
+// time, and so need not fit in memory. Input following the array results in
+// ErrTrailingData. When an error occurs, the lines for the preceding
+// elements are complete.
+func SplitArray(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(append([]Option{WithMinify(true)}, opts...))
+
+	s := newState(w, newDecoder(r, c), c)
+	return s.flushed(s.split())
+}
+
+// split writes each element of the array read to a line of its own.
+func (s *state) split() error {
+	t, err := s.token()
+	if err != nil {
+		return s.eof(err)
+	}
+	if t != json.Delim('[') {
+		return ErrNotArray
+	}
+	for n := 0; s.More(); n++ {
+		if s.limit(n) {
+			err = s.Flush()
+			if err != nil {
+				return err
+			}
+			return ErrMaxDocuments
+		}
+		err = s.any()
+		if err != nil {
+			return s.eof(err)
+		}
+		s.WriteByte('\n')
+		err = s.progress()
+		if err != nil {
+			return err
+		}
+	}
+	// this will be ']'
+	_, err = s.token()
+	if err != nil {
+		return s.eof(err)
+	}
+	_, err = s.token()
+	switch {
+	case err == nil:
+		return ErrTrailingData
+	case err != io.EOF:
+		return err
+	}
+	return s.Flush()
+}
+
+// JoinLines reads a sequence of whitespace-separated values from r, such as
+// newline-delimited JSON, and writes them to w as the elements of a single
+// array. Values are formatted as they are read, and so the input need not fit
+// in memory. Empty input produces an empty array, written as [] in any style.
+// When an error occurs, the elements preceding it have been written.
+func JoinLines(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	return s.flushed(s.joinLines())
+}
+
+// joinLines writes the values read as the elements of an array.
+func (s *state) joinLines() error {
+	s.push(array)
+	s.open('[')
+	n := 0
+	for ; s.More() && !s.limit(n); n++ {
+		s.sep(n == 0)
+		s.elem()
+		err := s.any()
+		if err == nil {
+			err = s.progress()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		s.punc(']')
+	} else {
+		s.close(']', false)
+	}
+	s.pop()
+	if s.More() {
+		err := s.end()
+		if err != nil {
+			return err
+		}
+		return ErrMaxDocuments
+	}
+	_, err := s.Token()
+	if err != io.EOF {
+		return err
+	}
+	return s.end()
+}
+
+// FormatStream reads a sequence of whitespace-separated values from r, such
+// as newline-delimited JSON, and writes each to w, formatted independently.
+// Successive documents are separated as set by WithDocumentSeparator. With
+// WithMinify, the output is newline-delimited JSON, regardless of how the
+// input was formatted. Empty input produces no output. When an error occurs,
+// the output for the preceding documents is complete.
+func FormatStream(w io.Writer, r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	return s.flushed(s.sequence())
+}
+
+// sequence formats each of the values read.
+func (s *state) sequence() error {
+	n := 0
+	for ; s.More(); n++ {
+		if s.limit(n) {
+			err := s.end()
+			if err != nil {
+				return err
+			}
+			return ErrMaxDocuments
+		}
+		if n > 0 {
+			s.flushComment()
+			s.WriteString(s.docSep)
+		}
+		err := s.any()
+		if err != nil {
+			return s.eof(err)
+		}
+		err = s.progress()
+		if err != nil {
+			return err
+		}
+	}
+	_, err := s.Token()
+	if err != io.EOF {
+		return err
+	}
+	if n == 0 {
+		return s.Flush()
+	}
+	return s.end()
+}
+
+// WithDocumentSeparator sets the string written between successive documents
+// by FormatStream, FormatFrames and Query, which is a newline by default.
+// The trailing newline, if any, follows the last document alone.
+func WithDocumentSeparator(sep string) Option {
+	return func(c *config) { c.docSep = sep }
+}
+
+// ErrTrailingData is returned when input holding a single value continues
+// beyond the end of that value.
+var ErrTrailingData = errors.New("jsonaux: unexpected data after top-level value")
+
+// Concat reads a single value from each of readers, and writes them to w as
+// the elements of a single array. Only one element is held in memory at a
+// time. Each reader must hold exactly one value; trailing data results in an
+// error wrapping ErrTrailingData, and an empty reader in one wrapping
+// ErrEmptyInput. Errors are reported as a *PathError naming the index of the
+// offending element. Empty readers produce an empty array, written as [] in
+// any style.
+func Concat(w io.Writer, readers []io.Reader, opts ...Option) error {
+	return concat(w, len(readers), func(i int) io.Reader { return readers[i] }, opts)
+}
+
+// FormatRawArray writes msgs to w as the elements of a single array. Each
+// message is validated as it is formatted, and must hold exactly one value.
+// Errors are reported as a *PathError naming the index of the offending
+// element. Empty msgs produce an empty array, written as [] in any style.
+func FormatRawArray(w io.Writer, msgs []json.RawMessage, opts ...Option) error {
+	return concat(w, len(msgs), func(i int) io.Reader { return bytes.NewReader(msgs[i]) }, opts)
+}
+
+// concat formats the values read from n readers as a single array.
+func concat(w io.Writer, n int, reader func(int) io.Reader, opts []Option) error {
+	c := newConfig(opts)
+
+	s := newState(w, nil, c)
+	s.push(array)
+	s.open('[')
+	for i := 0; i < n; i++ {
+		dec := newDecoder(reader(i), c)
+		s.tokenSource = dec
+		s.sep(i == 0)
+		s.elem()
+		ntok := s.ntok
+		err := s.any()
+		switch {
+		case err == io.EOF && s.ntok-ntok <= 1:
+			err = ErrEmptyInput
+		case err == io.EOF:
+			err = io.ErrUnexpectedEOF
+		case err == nil:
+			err = s.progress()
+		}
+		if err == nil {
+			_, err = dec.Token()
+			if err == nil {
+				err = ErrTrailingData
+			}
+			if err == io.EOF {
+				continue
+			}
+		}
+		if _, ok := err.(*PathError); !ok {
+			err = &PathError{Path: s.path(), Err: err}
+		}
+		return err
+	}
+	if n == 0 {
+		s.punc(']')
+	} else {
+		s.close(']', false)
+	}
+	s.pop()
+	return s.end()
+}
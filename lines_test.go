@@ -0,0 +1,160 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLinesErrorKeepsOutput(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func(w *strings.Builder, in string) error
+		in   string
+		want string
+	}{
+		{"SplitArray", func(w *strings.Builder, in string) error {
+			return SplitArray(w, strings.NewReader(in))
+		}, `[1, {"a": 2}, ]`, "1\n{\"a\":2}\n"},
+		{"JoinLines", func(w *strings.Builder, in string) error {
+			return JoinLines(w, strings.NewReader(in), WithMinify(true))
+		}, "1 ]", "[1]"},
+		{"FormatStream", func(w *strings.Builder, in string) error {
+			return FormatStream(w, strings.NewReader(in), WithMinify(true))
+		}, "1\n[2]\n]", "1\n[2]"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		if err := tt.fn(&b, tt.in); err == nil {
+			t.Errorf("%s(%q) succeeded", tt.name, tt.in)
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("%s(%q) wrote %q, want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConcatErrors(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want error
+		path string
+	}{
+		{[]string{"1", ""}, ErrEmptyInput, "/1"},
+		{[]string{"  \n"}, ErrEmptyInput, "/0"},
+		{[]string{"1 2"}, ErrTrailingData, "/0"},
+	}
+	for _, tt := range tests {
+		var readers []io.Reader
+		msgs := make([]json.RawMessage, len(tt.in))
+		for i, in := range tt.in {
+			readers = append(readers, strings.NewReader(in))
+			msgs[i] = json.RawMessage(in)
+		}
+		for name, err := range map[string]error{
+			"Concat":         Concat(io.Discard, readers),
+			"FormatRawArray": FormatRawArray(io.Discard, msgs),
+		} {
+			var pe *PathError
+			if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.want) {
+				t.Errorf("%s(%q) = %v, want %v at %q", name, tt.in, err, tt.want, tt.path)
+			}
+		}
+	}
+}
+
+func TestConcatEmpty(t *testing.T) {
+	for _, style := range []CommaStyle{CommaPrefix, CommaSuffix} {
+		var b strings.Builder
+		if err := Concat(&b, nil, WithCommaStyle(style)); err != nil || b.String() != "[]\n" {
+			t.Errorf("Concat(nil), style %v = %q, %v, want %q", style, b.String(), err, "[]\n")
+		}
+		b.Reset()
+		if err := FormatRawArray(&b, nil, WithCommaStyle(style)); err != nil || b.String() != "[]\n" {
+			t.Errorf("FormatRawArray(nil), style %v = %q, %v, want %q", style, b.String(), err, "[]\n")
+		}
+	}
+}
+
+func TestSplitArray(t *testing.T) {
+	tests := []struct {
+		in   string
+		opts []Option
+		want string
+	}{
+		{`[]`, nil, ""},
+		{` [ 1 , "x" ] `, nil, "1\n\"x\"\n"},
+		{`[[1,[2]],{"a":{"b":[]}},null]`, nil, "[1,[2]]\n{\"a\":{\"b\":[]}}\nnull\n"},
+		{`[{"a":1}]`, []Option{WithMinify(false)}, "{ \"a\": 1\n}\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := SplitArray(&b, strings.NewReader(tt.in), tt.opts...)
+		if err != nil || b.String() != tt.want {
+			t.Errorf("SplitArray(%q) = %q, %v, want %q", tt.in, b.String(), err, tt.want)
+		}
+	}
+}
+
+func TestSplitArrayErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want error // nil for a syntax error
+	}{
+		{"", ErrEmptyInput},
+		{"  \n", ErrEmptyInput},
+		{`{"a":[]}`, ErrNotArray},
+		{`1`, ErrNotArray},
+		{`[1] 2`, ErrTrailingData},
+		{`[1] [2]`, ErrTrailingData},
+		{`[1`, nil},
+		{`[1 2]`, nil},
+		{`[1,]`, nil},
+		{`[1]]`, nil},
+	}
+	for _, tt := range tests {
+		err := SplitArray(io.Discard, strings.NewReader(tt.in))
+		if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("SplitArray(%q) = %v, want %v", tt.in, err, tt.want)
+		}
+	}
+}
+
+func TestJoinLines(t *testing.T) {
+	tests := []struct {
+		in   string
+		opts []Option
+		want string
+	}{
+		{"", nil, "[]\n"},
+		{"  \n\n", nil, "[]\n"},
+		{"", []Option{WithCommaStyle(CommaSuffix)}, "[]\n"},
+		{"1\n\"x\"\nnull\n", []Option{WithMinify(true)}, "[1,\"x\",null]\n"},
+		{`{"a":[1,{"b":[]}]} [2] 3`, []Option{WithMinify(true)}, "[{\"a\":[1,{\"b\":[]}]},[2],3]\n"},
+		{"{\"a\":1}\n[2]\n3", nil, "[ { \"a\": 1\n  }\n, [ 2\n  ]\n, 3\n]\n"},
+		{"1\n2", []Option{WithCommaStyle(CommaSuffix)}, "[\n  1,\n  2\n]\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := JoinLines(&b, strings.NewReader(tt.in), tt.opts...)
+		if err != nil || b.String() != tt.want {
+			t.Errorf("JoinLines(%q) = %q, %v, want %q", tt.in, b.String(), err, tt.want)
+		}
+	}
+}
+
+func TestJoinLinesErrors(t *testing.T) {
+	for _, in := range []string{
+		"1\n[2",
+		"1\n{\"a\":}",
+		"1 ]",
+		"1\n2 x",
+		"1,2",
+	} {
+		if err := JoinLines(io.Discard, strings.NewReader(in)); err == nil {
+			t.Errorf("JoinLines(%q) succeeded, want an error", in)
+		}
+	}
+}
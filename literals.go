@@ -0,0 +1,26 @@
+package jsonaux
+
+// Literals holds the spellings used for the literal names true, false, and
+// null. An empty field selects the standard spelling.
+type Literals struct {
+	True, False, Null string
+}
+
+// PythonLiterals spells the literal names as Python does.
+var PythonLiterals = Literals{True: "True", False: "False", Null: "None"}
+
+// WithLiteralCasing sets the spellings used for true, false, and null, so
+// that the formatter's layout may be reused for JSON-like formats, such as
+// Python literals. Any non-standard spelling produces output which is not
+// JSON, so the standard spellings are used by default.
+func WithLiteralCasing(l Literals) Option {
+	return func(c *config) { c.literals = l }
+}
+
+// or returns s, or def if s is empty.
+func or(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
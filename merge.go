@@ -0,0 +1,136 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ArrayMerge determines how Merge combines a pair of arrays.
+type ArrayMerge uint8
+
+const (
+	// ArrayReplace uses the overlay array, discarding the base array.
+	ArrayReplace ArrayMerge = iota
+
+	// ArrayConcat appends the overlay elements to the base elements.
+	ArrayConcat
+
+	// ArrayIndex merges elements at the same index, keeping any excess
+	// elements from the longer array.
+	ArrayIndex
+)
+
+// WithArrayMerge sets the policy used by Merge for arrays present in both
+// documents. The default is ArrayReplace.
+func WithArrayMerge(m ArrayMerge) Option {
+	return func(c *config) { c.arrayMerge = m }
+}
+
+// Merge recursively merges the value read from overlay into the value read
+// from base, and formats the result to w. Objects are merged member by
+// member, keeping the key order of base and appending keys new to overlay.
+// Arrays are combined according to WithArrayMerge. In all other cases, the
+// overlay value wins.
+//
+// Unlike a JSON Merge Patch (RFC 7386), as applied by ApplyMergePatch, null
+// is an ordinary value and does not delete members.
+func Merge(w io.Writer, base, overlay io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	b, err := readDocument(newDecoder(base, c))
+	if err != nil {
+		return err
+	}
+	o, err := readDocument(newDecoder(overlay, c))
+	if err != nil {
+		return err
+	}
+	return format(w, merge(b, o, c.arrayMerge).replay(), c)
+}
+
+// ApplyMergePatch reads a value from doc and a JSON Merge Patch (RFC 7386)
+// from patch, applies the patch to the value, and formats the result to w.
+// Members of the patch with a null value delete the corresponding members of
+// the value, and those of other objects are applied recursively; any other
+// patch replaces the value outright.
+func ApplyMergePatch(w io.Writer, doc, patch io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	d, err := readDocument(newDecoder(doc, c))
+	if err != nil {
+		return err
+	}
+	p, err := readDocument(newDecoder(patch, c))
+	if err != nil {
+		return err
+	}
+	return format(w, mergePatch(d, p).replay(), c)
+}
+
+// mergePatch applies the merge patch p to d, returning the result.
+func mergePatch(d, p *Document) *Document {
+	if !p.IsObject() {
+		return p
+	}
+	if d == nil || !d.IsObject() {
+		d = &Document{Token: json.Delim('{')}
+	}
+	for _, m := range p.Members {
+		if m.Value.Token == nil {
+			members := d.Members[:0]
+			for _, dm := range d.Members {
+				if dm.Key != m.Key {
+					members = append(members, dm)
+				}
+			}
+			d.Members = members
+			continue
+		}
+		i := len(d.Members) - 1
+		for i >= 0 && d.Members[i].Key != m.Key {
+			i--
+		}
+		if i < 0 {
+			d.Members = append(d.Members, Member{m.Key, mergePatch(nil, m.Value)})
+		} else {
+			d.Members[i].Value = mergePatch(d.Members[i].Value, m.Value)
+		}
+	}
+	return d
+}
+
+func merge(b, o *Document, m ArrayMerge) *Document {
+	switch {
+	case b.IsObject() && o.IsObject():
+		index := make(map[string]int, len(b.Members))
+		for i, mem := range b.Members {
+			index[mem.Key] = i
+		}
+		for _, mem := range o.Members {
+			i, ok := index[mem.Key]
+			if !ok {
+				index[mem.Key] = len(b.Members)
+				b.Members = append(b.Members, mem)
+				continue
+			}
+			b.Members[i].Value = merge(b.Members[i].Value, mem.Value, m)
+		}
+		return b
+	case b.IsArray() && o.IsArray():
+		switch m {
+		case ArrayConcat:
+			b.Elements = append(b.Elements, o.Elements...)
+			return b
+		case ArrayIndex:
+			for i, e := range o.Elements {
+				if i < len(b.Elements) {
+					b.Elements[i] = merge(b.Elements[i], e, m)
+				} else {
+					b.Elements = append(b.Elements, e)
+				}
+			}
+			return b
+		}
+	}
+	return o
+}
@@ -0,0 +1,61 @@
+package jsonaux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMerge(t *testing.T) {
+	const base = `{"a":1,"b":{"x":[1,{"p":1}],"y":1.0},"c":[1],"n":{"k":1}}`
+	const overlay = `{"b":{"x":[[3],{"q":2},4],"z":null},"d":true,"a":2,"n":null}`
+	tests := []struct {
+		m    ArrayMerge
+		want string
+	}{
+		{ArrayReplace, `{"a":2,"b":{"x":[[3],{"q":2},4],"y":1.0,"z":null},"c":[1],"n":null,"d":true}`},
+		{ArrayConcat, `{"a":2,"b":{"x":[1,{"p":1},[3],{"q":2},4],"y":1.0,"z":null},"c":[1],"n":null,"d":true}`},
+		{ArrayIndex, `{"a":2,"b":{"x":[[3],{"p":1,"q":2},4],"y":1.0,"z":null},"c":[1],"n":null,"d":true}`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := Merge(&b, strings.NewReader(base), strings.NewReader(overlay), WithArrayMerge(tt.m), WithMinify(true))
+		if err != nil || b.String() != tt.want+"\n" {
+			t.Errorf("Merge, mode %d = %s, %v, want %s", tt.m, b.String(), err, tt.want)
+		}
+	}
+}
+
+func TestMergeValues(t *testing.T) {
+	tests := []struct {
+		base, overlay string
+		want          string
+	}{
+		// the overlay wins unless both are objects, or arrays
+		{`{"a":1}`, `[1]`, `[1]`},
+		{`[1]`, `{"a":1}`, `{"a":1}`},
+		{`1`, `"x"`, `"x"`},
+		// null is an ordinary value
+		{`{"a":1}`, `null`, `null`},
+		{`null`, `{"a":1}`, `{"a":1}`},
+		{`{"a":{"b":1}}`, `{"a":null}`, `{"a":null}`},
+		{`{"a":null}`, `{"a":{"b":1}}`, `{"a":{"b":1}}`},
+		// nested objects merge at every depth
+		{`{"a":{"b":{"c":1,"d":2}}}`, `{"a":{"b":{"d":3,"e":4}}}`, `{"a":{"b":{"c":1,"d":3,"e":4}}}`},
+		{`{}`, `{"a":1}`, `{"a":1}`},
+		{`{"a":1}`, `{}`, `{"a":1}`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := Merge(&b, strings.NewReader(tt.base), strings.NewReader(tt.overlay), WithMinify(true))
+		if err != nil || b.String() != tt.want+"\n" {
+			t.Errorf("Merge(%s, %s) = %s, %v, want %s", tt.base, tt.overlay, b.String(), err, tt.want)
+		}
+	}
+
+	for _, tt := range [][2]string{{`{`, `{}`}, {`{}`, `[1,`}, {``, `1`}} {
+		err := Merge(new(strings.Builder), strings.NewReader(tt[0]), strings.NewReader(tt[1]))
+		if err == nil {
+			t.Errorf("Merge(%q, %q) succeeded, want an error", tt[0], tt[1])
+		}
+	}
+}
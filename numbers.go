@@ -0,0 +1,89 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// maxSafeInt is the largest integer which JavaScript can represent exactly,
+// 2^53-1, known there as Number.MAX_SAFE_INTEGER.
+const maxSafeInt = 1<<53 - 1
+
+// WithQuoteBigInts controls whether integers too large to be represented
+// exactly by JavaScript are emitted as strings, so that consumers may parse
+// them without loss of precision. Only numbers spelled as integers, without a
+// fraction or exponent, are affected. Since this changes the type of the
+// affected values, it is off by default.
+func WithQuoteBigInts(quote bool) Option {
+	return func(c *config) { c.quoteBig = quote }
+}
+
+// WithMaxSafeInt sets the largest magnitude of integer left unquoted by
+// WithQuoteBigInts. The default is 2^53-1.
+func WithMaxSafeInt(n uint64) Option {
+	return func(c *config) { c.maxSafe = strconv.FormatUint(n, 10) }
+}
+
+// bigInt reports whether n is an integer of greater magnitude than the
+// configured limit.
+func (c *config) bigInt(n json.Number) bool {
+	digits := strings.TrimPrefix(string(n), "-")
+	if strings.ContainsAny(digits, ".eE") {
+		return false
+	}
+	limit := c.maxSafe
+	if limit == "" {
+		limit = strconv.FormatUint(maxSafeInt, 10)
+	}
+	// JSON forbids leading zeros, so longer means larger
+	if len(digits) != len(limit) {
+		return len(digits) > len(limit)
+	}
+	return digits > limit
+}
+
+// WithUnquoteNumbers controls whether string values holding a valid JSON
+// number, such as "42" or "1e3", are emitted as numbers. Object keys are
+// never affected. Since this changes the type of the affected values, it is
+// off by default.
+func WithUnquoteNumbers(unquote bool) Option {
+	return func(c *config) { c.unquote = unquote }
+}
+
+// isNumber reports whether s conforms to the JSON number grammar.
+func isNumber(s string) bool {
+	i := 0
+	digits := func() int {
+		j := i
+		for i < len(s) && '0' <= s[i] && s[i] <= '9' {
+			i++
+		}
+		return i - j
+	}
+	if i < len(s) && s[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+	case digits() == 0:
+		return false
+	}
+	if i < len(s) && s[i] == '.' {
+		i++
+		if digits() == 0 {
+			return false
+		}
+	}
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		if digits() == 0 {
+			return false
+		}
+	}
+	return i == len(s)
+}
@@ -0,0 +1,29 @@
+package jsonaux
+
+import "testing"
+
+func TestUnquoteNumbers(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{`"42"`, `42`},
+		{`"3.14"`, `3.14`},
+		{`"1e3"`, `1e3`},
+		{`"-0.5E+2"`, `-0.5E+2`},
+		{`"abc"`, `"abc"`},
+		{`"42abc"`, `"42abc"`},
+		{`" 42"`, `" 42"`},
+		{`"0x1F"`, `"0x1F"`},
+		{`"01"`, `"01"`},
+		{`"1."`, `"1."`},
+		{`""`, `""`},
+		{`{"42":"42"}`, `{"42":42}`},
+	}
+	for _, tt := range tests {
+		got := formatString(t, tt.in, WithUnquoteNumbers(true), WithMinify(true))
+		if got != tt.want+"\n" {
+			t.Errorf("Format(%s) = %q, want %q", tt.in, got, tt.want+"\n")
+		}
+	}
+	if got := formatString(t, `"42"`, WithMinify(true)); got != "\"42\"\n" {
+		t.Errorf("Format without unquoting = %q", got)
+	}
+}
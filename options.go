@@ -0,0 +1,262 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// Option adjusts the output produced by the formatting functions.
+type Option func(*config)
+
+type config struct {
+	// layout
+	min          bool
+	indentUnit   string
+	commas       CommaStyle
+	initDepth    int
+	compact      bool
+	width        int
+	noNewline    bool
+	smartNewline bool
+	bom          bool
+	flushEvery   int
+	colorMode    ColorMode
+	palette      *palette
+	pathComments bool
+
+	trailingComma bool
+	unquotedKeys  bool
+	blankTop      bool
+	docSep        string
+	filter        string
+	flattenSep    string
+
+	// input
+	decoder  func(*json.Decoder)
+	utf8     InvalidUTF8
+	lenient  bool
+	comments bool
+	keep     bool
+	timeout  time.Duration
+
+	// values
+	rawJS       bool
+	ascii       bool
+	quoteBig    bool
+	maxSafe     string
+	unquote     bool
+	foldSpace   bool
+	canonical   bool
+	noHTML      bool
+	keyMapper   func(path, key string) string
+	valueMapper func(path string, t json.Token) json.Token
+	literals    Literals
+
+	// ordering
+	sortBy    string
+	sortKeys  bool
+	sortDepth int
+
+	keyCompare  func(a, b string) int
+	keyPriority map[string]int
+	dupKeys     DuplicateKeys
+
+	// limits
+	maxKey    int
+	maxErrors int
+	maxDocs   int
+	maxOutput int64
+	maxDepth  int
+	maxToken  int
+	maxInput  int64
+
+	requireContainer bool
+
+	// other functionality
+	arrayMerge  ArrayMerge
+	diffContext int
+	shape       bool
+}
+
+// newConfig returns the default configuration, as modified by opts.
+func newConfig(opts []Option) config {
+	c := config{indentUnit: "  ", docSep: "\n", diffContext: 3}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// WithMinify controls whether insignificant whitespace is omitted from the
+// output.
+func WithMinify(min bool) Option {
+	return func(c *config) { c.min = min }
+}
+
+// WithIndent sets the string written once for each level of indentation,
+// which is two spaces by default. The string should consist of whitespace,
+// lest the output be invalid.
+func WithIndent(indent string) Option {
+	return func(c *config) { c.indentUnit = indent }
+}
+
+// WithInitialDepth indents every line after the first by an additional n
+// levels, so that the output may be embedded within already indented text.
+// The first line is never indented, since it is expected to continue a line
+// begun by the caller, such as one holding a key; callers splicing the value
+// onto a line of its own should write the leading indentation themselves.
+func WithInitialDepth(n int) Option {
+	return func(c *config) { c.initDepth = n }
+}
+
+// bom is the UTF-8 encoding of U+FEFF, the byte order mark.
+const bom = "\ufeff"
+
+// WithOutputBOM controls whether the output begins with a UTF-8 byte order
+// mark, as expected by some Windows software. It is written once, before any
+// other output. The default is to omit it.
+func WithOutputBOM(enable bool) Option {
+	return func(c *config) { c.bom = enable }
+}
+
+// WithKeyMapper supplies a function which determines the key emitted for
+// each object member, allowing keys to be renamed. It is passed the JSON
+// Pointer of the enclosing object within the input, and the member's key as
+// it appears in the input. If fn returns the empty string, the member is
+// omitted from the output entirely. Mapping distinct keys to the same key
+// produces duplicate keys, which are handled as set by WithDuplicateKeys.
+func WithKeyMapper(fn func(path, key string) string) Option {
+	return func(c *config) { c.keyMapper = fn }
+}
+
+// WithValueMapper supplies a function which determines the value emitted for
+// each scalar, allowing values to be redacted, rounded, and so on. It is
+// passed the JSON Pointer of the value within the input, and the value as
+// decoded with UseNumber: nil, a bool, a string, or a json.Number. It must
+// return a token of one of those types, and may return t unchanged. The
+// function is not called for objects or arrays.
+func WithValueMapper(fn func(path string, t json.Token) json.Token) Option {
+	return func(c *config) { c.valueMapper = fn }
+}
+
+// WithTrailingNewline controls whether the output ends with a newline. The
+// default is to include it.
+func WithTrailingNewline(enable bool) Option {
+	return func(c *config) { c.noNewline = !enable }
+}
+
+// WithSmartNewline controls whether the trailing newline is included only
+// when writing to a terminal, where it keeps the prompt off the last line of
+// output, and omitted otherwise, such as when piping to another program. A
+// terminal is detected only when the writer is an *os.File; on platforms
+// other than Linux, macOS, the BSDs and Windows, any character device, such
+// as /dev/null, is taken to be one. For other writers, WithTrailingNewline
+// applies as usual.
+func WithSmartNewline(enable bool) Option {
+	return func(c *config) { c.smartNewline = enable }
+}
+
+// WithFlushInterval causes output to be flushed to the underlying writer
+// after every n documents, or elements of a top-level array, rather than only
+// once formatting completes. This is chiefly of use for functions handling a
+// sequence of documents, such as SplitArray and JoinLines, when writing
+// long-running streams to a network connection or similar. More frequent
+// flushing reduces latency at the expense of throughput. The output is
+// always flushed upon completion, regardless of n.
+func WithFlushInterval(n int) Option {
+	return func(c *config) { c.flushEvery = n }
+}
+
+// CommaStyle selects the placement of the commas separating the members of
+// objects and the elements of arrays.
+type CommaStyle uint8
+
+const (
+	// CommaPrefix places each comma at the start of the line holding the
+	// following member, aligned with the opening bracket. It is the
+	// default.
+	CommaPrefix CommaStyle = iota
+
+	// CommaSuffix places each comma at the end of the line holding the
+	// preceding member, in the manner of json.Indent.
+	CommaSuffix
+)
+
+// WithCommaStyle selects the comma placement used for multi-line output.
+// Reformatting output of one style with the other converts between them.
+func WithCommaStyle(cs CommaStyle) Option {
+	return func(c *config) { c.commas = cs }
+}
+
+// WithTrailingComma controls whether the last member or element of each
+// multi-line object or array is followed by a comma, so that appending
+// another changes only one line. The result is JSON5 rather than JSON, and is
+// not accepted by standard parsers. It applies only to the CommaSuffix style,
+// since CommaPrefix output already has this property, and never to minified
+// output, empty composites, or those laid out on a single line.
+func WithTrailingComma(enable bool) Option {
+	return func(c *config) { c.trailingComma = enable }
+}
+
+// WithUnquotedKeys controls whether object keys which are ASCII identifiers,
+// such as name or _id2, are written without quotes. Like WithTrailingComma,
+// the result is JSON5 rather than JSON; each such option may be enabled
+// independently of the others, to suit what the consumer accepts. Other keys
+// are always quoted.
+func WithUnquotedKeys(enable bool) Option {
+	return func(c *config) { c.unquotedKeys = enable }
+}
+
+// WithBlankLineBetweenTopLevel controls whether an empty line separates the
+// members or elements of the top-level object or array, grouping them visibly
+// in large files. Nested composites are unaffected. The extra whitespace is
+// cosmetic, and the output remains valid JSON. It never applies to minified
+// output.
+func WithBlankLineBetweenTopLevel(enable bool) Option {
+	return func(c *config) { c.blankTop = enable }
+}
+
+// WithDecoder supplies a function which is called to further configure each
+// json.Decoder used to read input. It is an escape hatch for needs not
+// otherwise met by this package. Input is otherwise read by the package's own
+// tokenizer, which is considerably faster, so supplying it has a cost.
+//
+// The decoder has already had UseNumber called, which json.Decoder provides
+// no way to undo; the formatter depends upon it to emit numbers exactly as
+// spelled in the input, without loss of precision.
+func WithDecoder(fn func(*json.Decoder)) Option {
+	return func(c *config) { c.decoder = fn }
+}
+
+// FormatOptions holds the most commonly adjusted settings as plain fields,
+// as an alternative to Options. Its zero value requests the defaults.
+type FormatOptions struct {
+	Indent            string     // unit of indentation; two spaces if empty
+	Minify            bool       // omit insignificant whitespace
+	Commas            CommaStyle // comma placement for multi-line output
+	NoTrailingNewline bool       // omit the newline ending the output
+	Filter            string     // a query selecting the values emitted
+}
+
+// Options returns the Options equivalent to o, for use with functions other
+// than FormatWith, or in combination with further Options.
+func (o FormatOptions) Options() []Option {
+	opts := []Option{
+		WithMinify(o.Minify),
+		WithCommaStyle(o.Commas),
+		WithTrailingNewline(!o.NoTrailingNewline),
+	}
+	if o.Indent != "" {
+		opts = append(opts, WithIndent(o.Indent))
+	}
+	if o.Filter != "" {
+		opts = append(opts, WithFilter(o.Filter))
+	}
+	return opts
+}
+
+// FormatWith is like Format, but takes its settings from o.
+func FormatWith(w io.Writer, r io.Reader, o FormatOptions) error {
+	return Format(w, r, o.Options()...)
+}
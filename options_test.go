@@ -0,0 +1,48 @@
+package jsonaux
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestCommaStyleConversion reformats each comma-prefix golden file in
+// testdata/commas with CommaSuffix, expecting the matching comma-suffix
+// golden file, and checks that each style is stable and converts back.
+func TestCommaStyleConversion(t *testing.T) {
+	prefixes, err := filepath.Glob("testdata/commas/*.prefix.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefixes) == 0 {
+		t.Fatal("no golden files")
+	}
+	for _, name := range prefixes {
+		prefix := readFile(t, name)
+		suffix := readFile(t, strings.TrimSuffix(name, ".prefix.json")+".suffix.json")
+
+		got := formatString(t, prefix, WithCommaStyle(CommaSuffix))
+		if got != suffix {
+			t.Errorf("%s in comma-suffix style:\n%s\nwant:\n%s", name, got, suffix)
+		}
+		if again := formatString(t, got, WithCommaStyle(CommaSuffix)); again != got {
+			t.Errorf("%s: comma-suffix reformat changed:\n%s", name, again)
+		}
+		if back := formatString(t, got); back != prefix {
+			t.Errorf("%s: converted back to comma-prefix style:\n%s\nwant:\n%s", name, back, prefix)
+		}
+		if again := formatString(t, prefix); again != prefix {
+			t.Errorf("%s: comma-prefix reformat changed:\n%s", name, again)
+		}
+	}
+}
+
+func readFile(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
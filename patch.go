@@ -0,0 +1,332 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+)
+
+// Diff reads a value from each of a and b, and writes to w a JSON Patch
+// (RFC 6902) transforming the first into the second, formatted with opts.
+// Objects are compared member by member, regardless of order. Arrays are
+// compared element by element at each index, with any excess elements added
+// or removed at the end; an element inserted near the start of an array thus
+// produces a change to each following element. Numbers are equal only if
+// spelled alike. Should an object hold a key more than once, the last
+// occurrence is used. The patch is an empty array if the values are equal.
+func Diff(w io.Writer, a, b io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	x, err := readDocument(newDecoder(a, c))
+	if err != nil {
+		return err
+	}
+	y, err := readDocument(newDecoder(b, c))
+	if err != nil {
+		return err
+	}
+	patch := &Document{Token: json.Delim('[')}
+	diff(patch, nil, x, y)
+	return format(w, patch.replay(), c)
+}
+
+// diff appends to patch the operations transforming x into y, at the
+// location given by ref.
+func diff(patch *Document, ref []string, x, y *Document) {
+	switch {
+	case x.IsObject() && y.IsObject():
+		mx, my := members(x), members(y)
+		for _, m := range x.Members {
+			if mx[m.Key] != m.Value {
+				continue // a duplicate
+			}
+			v := my[m.Key]
+			if v == nil {
+				addOp(patch, "remove", append(ref, m.Key), nil)
+			} else {
+				diff(patch, append(ref, m.Key), m.Value, v)
+			}
+		}
+		for _, m := range y.Members {
+			if my[m.Key] == m.Value && mx[m.Key] == nil {
+				addOp(patch, "add", append(ref, m.Key), m.Value)
+			}
+		}
+	case x.IsArray() && y.IsArray():
+		n := min(len(x.Elements), len(y.Elements))
+		for i := 0; i < n; i++ {
+			diff(patch, append(ref, strconv.Itoa(i)), x.Elements[i], y.Elements[i])
+		}
+		for i := len(x.Elements) - 1; i >= n; i-- {
+			addOp(patch, "remove", append(ref, strconv.Itoa(i)), nil)
+		}
+		for i := n; i < len(y.Elements); i++ {
+			addOp(patch, "add", append(ref, strconv.Itoa(i)), y.Elements[i])
+		}
+	case !equal(x, y):
+		addOp(patch, "replace", ref, y)
+	}
+}
+
+// addOp appends an operation to patch.
+func addOp(patch *Document, op string, ref []string, value *Document) {
+	d := &Document{Token: json.Delim('{'), Members: []Member{
+		{"op", &Document{Token: op}},
+		{"path", &Document{Token: formatPointer(ref)}},
+	}}
+	if value != nil {
+		d.Members = append(d.Members, Member{"value", value})
+	}
+	patch.Elements = append(patch.Elements, d)
+}
+
+// ErrInvalidPatch is returned, wrapped in a *PathError naming the offending
+// operation within the patch, when a JSON Patch is malformed.
+var ErrInvalidPatch = errors.New("jsonaux: invalid patch operation")
+
+// ErrTestFailed is returned, wrapped in a *PathError naming the offending
+// operation within the patch, when a JSON Patch test operation fails.
+var ErrTestFailed = errors.New("jsonaux: patch test failed")
+
+// ApplyPatch reads a value from doc and a JSON Patch (RFC 6902) from patch,
+// applies the patch to the value, and formats the result to w. The patch is
+// applied in full before any output is written, and not at all if any
+// operation fails. Errors are reported as a *PathError naming the failing
+// operation, such as /2 for the third, and wrapping ErrInvalidPatch,
+// ErrTestFailed, or ErrNotFound. Values are compared by test operations as by
+// Diff, so numbers are equal only if spelled alike. Should an object hold a
+// key more than once, operations use the last occurrence, except that remove
+// removes them all.
+func ApplyPatch(w io.Writer, doc, patch io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	d, err := readDocument(newDecoder(doc, c))
+	if err != nil {
+		return err
+	}
+	p, err := readDocument(newDecoder(patch, c))
+	if err != nil {
+		return err
+	}
+	if !p.IsArray() {
+		return &PathError{Path: "", Err: ErrInvalidPatch}
+	}
+	for i, op := range p.Elements {
+		d, err = applyOp(d, op)
+		if err != nil {
+			return &PathError{Path: "/" + strconv.Itoa(i), Err: err}
+		}
+	}
+	return format(w, d.replay(), c)
+}
+
+// applyOp applies a single patch operation to d, returning the result.
+func applyOp(d, op *Document) (*Document, error) {
+	if !op.IsObject() {
+		return nil, ErrInvalidPatch
+	}
+	name, _ := lookupString(op, "op")
+	ref, err := lookupPointer(op, "path")
+	if err != nil {
+		return nil, err
+	}
+	value := op.Lookup("value")
+	switch name {
+	case "add", "replace", "test":
+		if value == nil {
+			return nil, ErrInvalidPatch
+		}
+	case "move", "copy":
+		from, err := lookupPointer(op, "from")
+		if err != nil {
+			return nil, err
+		}
+		value = get(d, from)
+		if value == nil {
+			return nil, ErrNotFound
+		}
+		if name == "copy" {
+			value = value.clone()
+			break
+		}
+		if len(from) < len(ref) && equalRefs(from, ref[:len(from)]) {
+			return nil, ErrInvalidPatch // into one of its own children
+		}
+		d, err = remove(d, from)
+		if err != nil {
+			return nil, err
+		}
+	case "remove":
+		return remove(d, ref)
+	default:
+		return nil, ErrInvalidPatch
+	}
+
+	switch name {
+	case "replace":
+		if get(d, ref) == nil {
+			return nil, ErrNotFound
+		}
+		return set(d, ref, value, false)
+	case "test":
+		if v := get(d, ref); v == nil || !equal(v, value) {
+			return nil, ErrTestFailed
+		}
+		return d, nil
+	}
+	return set(d, ref, value, true)
+}
+
+// equalRefs reports whether a and b hold the same reference tokens.
+func equalRefs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// lookupString returns the string-valued member of op with the given key.
+func lookupString(op *Document, key string) (string, bool) {
+	v := op.Lookup(key)
+	if v == nil {
+		return "", false
+	}
+	str, ok := v.Token.(string)
+	return str, ok
+}
+
+// lookupPointer returns the reference tokens of the JSON Pointer held by the
+// member of op with the given key.
+func lookupPointer(op *Document, key string) ([]string, error) {
+	p, ok := lookupString(op, key)
+	if !ok {
+		return nil, ErrInvalidPatch
+	}
+	return parsePointer(p)
+}
+
+// get returns the value within d at ref, or nil if there is none.
+func get(d *Document, ref []string) *Document {
+	for _, name := range ref {
+		switch {
+		case d.IsObject():
+			d = d.Lookup(name)
+		case d.IsArray():
+			i, ok := index(name)
+			if !ok || i >= len(d.Elements) {
+				return nil
+			}
+			d = d.Elements[i]
+		default:
+			return nil
+		}
+		if d == nil {
+			return nil
+		}
+	}
+	return d
+}
+
+// set places v within d at ref, returning the result. An array element is
+// inserted if insert is set, and replaced otherwise.
+func set(d *Document, ref []string, v *Document, insert bool) (*Document, error) {
+	if len(ref) == 0 {
+		return v, nil
+	}
+	parent, name := get(d, ref[:len(ref)-1]), ref[len(ref)-1]
+	switch {
+	case parent == nil:
+		return nil, ErrNotFound
+	case parent.IsObject():
+		for i := len(parent.Members) - 1; i >= 0; i-- {
+			if parent.Members[i].Key == name {
+				parent.Members[i].Value = v
+				return d, nil
+			}
+		}
+		parent.Members = append(parent.Members, Member{name, v})
+	case parent.IsArray():
+		i, ok := index(name)
+		if name == "-" && insert {
+			i, ok = len(parent.Elements), true
+		}
+		if !ok || i > len(parent.Elements) || i == len(parent.Elements) && !insert {
+			return nil, ErrNotFound
+		}
+		if insert {
+			parent.Elements = append(parent.Elements, nil)
+			copy(parent.Elements[i+1:], parent.Elements[i:])
+		}
+		parent.Elements[i] = v
+	default:
+		return nil, ErrNotFound
+	}
+	return d, nil
+}
+
+// remove removes the value within d at ref, returning the result.
+func remove(d *Document, ref []string) (*Document, error) {
+	if get(d, ref) == nil {
+		return nil, ErrNotFound
+	}
+	if len(ref) == 0 {
+		return nil, ErrInvalidPatch
+	}
+	parent, name := get(d, ref[:len(ref)-1]), ref[len(ref)-1]
+	if parent.IsObject() {
+		members := parent.Members[:0]
+		for _, m := range parent.Members {
+			if m.Key != name {
+				members = append(members, m)
+			}
+		}
+		parent.Members = members
+		return d, nil
+	}
+	i, _ := index(name)
+	parent.Elements = append(parent.Elements[:i], parent.Elements[i+1:]...)
+	return d, nil
+}
+
+// equal reports whether x and y are equal, as Diff determines.
+func equal(x, y *Document) bool {
+	switch {
+	case x.IsObject() && y.IsObject():
+		mx, my := members(x), members(y)
+		if len(mx) != len(my) {
+			return false
+		}
+		for k, v := range mx {
+			if my[k] == nil || !equal(v, my[k]) {
+				return false
+			}
+		}
+		return true
+	case x.IsArray() && y.IsArray():
+		if len(x.Elements) != len(y.Elements) {
+			return false
+		}
+		for i, e := range x.Elements {
+			if !equal(e, y.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return x.Token == y.Token
+}
+
+// members maps the keys of an object to their values, as Lookup does.
+func members(d *Document) map[string]*Document {
+	m := make(map[string]*Document, len(d.Members))
+	for _, mem := range d.Members {
+		m[mem.Key] = mem.Value
+	}
+	return m
+}
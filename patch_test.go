@@ -0,0 +1,209 @@
+package jsonaux
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// diffString returns the minified patch written by Diff for a and b.
+func diffString(t *testing.T, a, b string) string {
+	t.Helper()
+	var out strings.Builder
+	err := Diff(&out, strings.NewReader(a), strings.NewReader(b), WithMinify(true), WithTrailingNewline(false))
+	if err != nil {
+		t.Fatalf("Diff(%s, %s): %v", a, b, err)
+	}
+	return out.String()
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{`{"b":1,"a":[{}]}`, `{"a":[{}],"b":1}`, `[]`},
+		{`1`, `[1]`, `[{"op":"replace","path":"","value":[1]}]`},
+		{`{"a":1}`, `{}`, `[{"op":"remove","path":"/a"}]`},
+		{`{}`, `{"a/b":{"c~":1}}`, `[{"op":"add","path":"/a~1b","value":{"c~":1}}]`},
+		{`[1,2,3]`, `[1,5]`, `[{"op":"replace","path":"/1","value":5},{"op":"remove","path":"/2"}]`},
+		{`[1,2,3]`, `[1]`, `[{"op":"remove","path":"/2"},{"op":"remove","path":"/1"}]`},
+		{`[1]`, `[1,[2],3]`, `[{"op":"add","path":"/1","value":[2]},{"op":"add","path":"/2","value":3}]`},
+		{`{"a":{"b":[true,null]}}`, `{"a":{"b":[false,null]}}`, `[{"op":"replace","path":"/a/b/0","value":false}]`},
+		{`{"e":[]}`, `{"e":{}}`, `[{"op":"replace","path":"/e","value":{}}]`},
+		{`{"a":1,"a":2}`, `{"a":2}`, `[]`},
+		{`0`, `0.0`, `[{"op":"replace","path":"","value":0.0}]`},
+	}
+	for _, tt := range tests {
+		if got := diffString(t, tt.a, tt.b); got != tt.want {
+			t.Errorf("Diff(%s, %s) = %s, want %s", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestDiffRoundTrip(t *testing.T) {
+	tests := [][2]string{
+		{`{"a":1,"b":[1,2,3],"c":{"x":true,"y":null},"d":"s","a/b":0,"e":[]}`,
+			`{"e":{},"b":[1,5],"c":{"y":null,"z":2,"x":true},"d":"s","f":[1],"a/b":0.0}`},
+		{`[1,[2,[3,4]],{"k":[]}]`, `[[2,[4]],{"k":[5]},1,6]`},
+		{`{"~":{"/":[{}]}}`, `{"~":{"/":[{"x":[]}],"~1":1}}`},
+		{`{"a":[1,2,3,4,5]}`, `{"a":[]}`},
+		{`"x"`, `{"a":"x"}`},
+		{`null`, `null`},
+	}
+	for _, tt := range tests {
+		patch := diffString(t, tt[0], tt[1])
+		var b strings.Builder
+		err := ApplyPatch(&b, strings.NewReader(tt[0]), strings.NewReader(patch))
+		if err != nil {
+			t.Errorf("ApplyPatch(%s, %s): %v", tt[0], patch, err)
+			continue
+		}
+		eq, err := Equal(strings.NewReader(b.String()), strings.NewReader(tt[1]))
+		if err != nil || !eq {
+			t.Errorf("ApplyPatch(%s, Diff(%s, %s)) = %s, %v", tt[0], tt[0], tt[1], b.String(), err)
+		}
+	}
+}
+
+func TestApplyPatch(t *testing.T) {
+	// RFC 6902, appendix A; the results are compared as by Equal
+	tests := []struct {
+		name       string
+		doc, patch string
+		want       string // empty if an error is expected
+	}{
+		{"A.1 adding an object member", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux"}]`,
+			`{"baz":"qux","foo":"bar"}`},
+		{"A.2 adding an array element", `{"foo":["bar","baz"]}`,
+			`[{"op":"add","path":"/foo/1","value":"qux"}]`,
+			`{"foo":["bar","qux","baz"]}`},
+		{"A.3 removing an object member", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"remove","path":"/baz"}]`,
+			`{"foo":"bar"}`},
+		{"A.4 removing an array element", `{"foo":["bar","qux","baz"]}`,
+			`[{"op":"remove","path":"/foo/1"}]`,
+			`{"foo":["bar","baz"]}`},
+		{"A.5 replacing a value", `{"baz":"qux","foo":"bar"}`,
+			`[{"op":"replace","path":"/baz","value":"boo"}]`,
+			`{"baz":"boo","foo":"bar"}`},
+		{"A.6 moving a value", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`,
+			`[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`,
+			`{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"A.7 moving an array element", `{"foo":["all","grass","cows","eat"]}`,
+			`[{"op":"move","from":"/foo/1","path":"/foo/3"}]`,
+			`{"foo":["all","cows","eat","grass"]}`},
+		{"A.8 testing a value: success", `{"baz":"qux","foo":["a",2,"c"]}`,
+			`[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`,
+			`{"baz":"qux","foo":["a",2,"c"]}`},
+		{"A.9 testing a value: error", `{"baz":"qux"}`,
+			`[{"op":"test","path":"/baz","value":"bar"}]`,
+			``},
+		{"A.10 adding a nested member object", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/child","value":{"grandchild":{}}}]`,
+			`{"foo":"bar","child":{"grandchild":{}}}`},
+		{"A.11 ignoring unrecognized elements", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","xyz":123}]`,
+			`{"foo":"bar","baz":"qux"}`},
+		{"A.12 adding to a nonexistent target", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz/bat","value":"qux"}]`,
+			``},
+		{"A.13 invalid JSON Patch document", `{"foo":"bar"}`,
+			`[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`,
+			``},
+		{"A.14 ~ escape ordering", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":10}]`,
+			`{"/":9,"~1":10}`},
+		{"A.15 comparing strings and numbers", `{"/":9,"~1":10}`,
+			`[{"op":"test","path":"/~01","value":"10"}]`,
+			``},
+		{"A.16 adding an array value", `{"foo":["bar"]}`,
+			`[{"op":"add","path":"/foo/-","value":["abc","def"]}]`,
+			`{"foo":["bar",["abc","def"]]}`},
+		{"replacing the whole document", `{"a":1}`,
+			`[{"op":"replace","path":"","value":[true]}]`,
+			`[true]`},
+		{"copying a value", `{"a":{"b":[1]}}`,
+			`[{"op":"copy","from":"/a/b","path":"/c"},{"op":"add","path":"/c/0","value":0}]`,
+			`{"a":{"b":[1]},"c":[0,1]}`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := ApplyPatch(&b, strings.NewReader(tt.doc), strings.NewReader(tt.patch))
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: ApplyPatch = %s, want an error", tt.name, b.String())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: ApplyPatch: %v", tt.name, err)
+			continue
+		}
+		eq, err := Equal(strings.NewReader(b.String()), strings.NewReader(tt.want))
+		if err != nil || !eq {
+			t.Errorf("%s: ApplyPatch = %s, want %s", tt.name, b.String(), tt.want)
+		}
+	}
+}
+
+func TestApplyPatchErrors(t *testing.T) {
+	const doc = `{"a":{"b":[1,2,3]},"c":"x","d":1}`
+	tests := []struct {
+		patch string
+		want  error
+		path  string
+	}{
+		{`{}`, ErrInvalidPatch, ""},
+		{`[{"op":"test","path":"/d","value":2}]`, ErrTestFailed, "/0"},
+		{`[{"op":"test","path":"/d","value":1},{"op":"remove","path":"/zz"}]`, ErrNotFound, "/1"},
+		{`[{"op":"add","path":"/a/b/4","value":1}]`, ErrNotFound, "/0"},
+		{`[{"op":"add","path":"/a/b/01","value":1}]`, ErrNotFound, "/0"},
+		{`[{"op":"replace","path":"/x/y","value":1}]`, ErrNotFound, "/0"},
+		{`[{"op":"nope","path":"/a"}]`, ErrInvalidPatch, "/0"},
+		{`[{"op":"add","path":"/a"}]`, ErrInvalidPatch, "/0"},
+		{`[{"op":"move","from":"/a","path":"/a/x"}]`, ErrInvalidPatch, "/0"},
+		{`[{"op":"add","path":"a","value":1}]`, ErrInvalidPointer, "/0"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := ApplyPatch(&b, strings.NewReader(doc), strings.NewReader(tt.patch))
+		var pe *PathError
+		if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.want) {
+			t.Errorf("ApplyPatch(%s) = %v, want %v at %q", tt.patch, err, tt.want, tt.path)
+		}
+		if b.Len() > 0 {
+			t.Errorf("ApplyPatch(%s) wrote %q despite failing", tt.patch, b.String())
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	// RFC 7386, appendix A, and another
+	tests := [][3]string{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"x":1,"y":2,"z":3}`, `{"x":5}`, `{"x":5,"y":2,"z":3}`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := ApplyMergePatch(&b, strings.NewReader(tt[0]), strings.NewReader(tt[1]), WithMinify(true), WithTrailingNewline(false))
+		if err != nil || b.String() != tt[2] {
+			t.Errorf("ApplyMergePatch(%s, %s) = %s, %v, want %s", tt[0], tt[1], b.String(), err, tt[2])
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// PluckField reads a top-level array of objects from r, and writes to w an
+// array holding the value of the named member of each, or null where it is
+// absent. Elements which are not objects likewise produce null. Should an
+// object hold the member more than once, the first occurrence is used. The
+// input is consumed one element at a time, and discarded members are never
+// held in memory, so the array need not fit in memory. When an error occurs,
+// the values plucked before it have been written.
+func PluckField(w io.Writer, r io.Reader, field string, opts ...Option) error {
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	return s.flushed(s.pluckField(field))
+}
+
+// pluckField writes the named member of each element of the array read.
+func (s *state) pluckField(field string) error {
+	t, err := s.token()
+	if err != nil {
+		return s.eof(err)
+	}
+	if t != json.Delim('[') {
+		return ErrNotArray
+	}
+
+	s.push(array)
+	s.open('[')
+	n := 0
+	for ; s.More(); n++ {
+		s.sep(n == 0)
+		s.elem()
+		err = s.pluck(field)
+		if err != nil {
+			return err
+		}
+	}
+	if n == 0 {
+		s.punc(']')
+	} else {
+		s.close(']', false)
+	}
+	s.pop()
+
+	// this will be ']'
+	_, err = s.token()
+	if err != nil {
+		return err
+	}
+	return s.end()
+}
+
+// pluck formats the named member of the next value, if it is an object
+// having such a member, or null otherwise.
+func (s *state) pluck(field string) error {
+	t, err := s.token()
+	if err != nil {
+		return err
+	}
+	if t != json.Delim('{') {
+		s.scalar(nil)
+		return s.skipRest(t)
+	}
+	found := false
+	for s.More() {
+		t, err = s.token()
+		if err != nil {
+			return err
+		}
+		if found || t != field {
+			err = s.skip()
+		} else {
+			found = true
+			err = s.any()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		s.scalar(nil)
+	}
+	// this will be '}'
+	_, err = s.token()
+	return err
+}
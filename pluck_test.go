@@ -0,0 +1,50 @@
+package jsonaux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPluckField(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`[{"id":1,"x":{"a":[1]}},{"x":2},{"id":{"n":[3]},"id":4}]`, `[1,null,{"n":[3]}]`},
+		{`[[1,{"id":5}],7,"id",null,{}]`, `[null,null,null,null,null]`},
+		{`[{"x":{"id":1},"id":[true]}]`, `[[true]]`},
+		{`[]`, `[]`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := PluckField(&b, strings.NewReader(tt.in), "id", WithMinify(true), WithTrailingNewline(false))
+		if err != nil || b.String() != tt.want {
+			t.Errorf("PluckField(%s) = %s, %v, want %s", tt.in, b.String(), err, tt.want)
+		}
+	}
+	for _, style := range []CommaStyle{CommaPrefix, CommaSuffix} {
+		var b strings.Builder
+		if err := PluckField(&b, strings.NewReader(`[]`), "id", WithCommaStyle(style)); err != nil || b.String() != "[]\n" {
+			t.Errorf("PluckField([]), style %v = %q, %v, want %q", style, b.String(), err, "[]\n")
+		}
+	}
+}
+
+func TestPluckFieldErrors(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string // the output preceding the error
+	}{
+		{``, ``},
+		{`{"id":1}`, ``},
+		{`[{"id":1},{"x":2},{"id":`, `[1,null,`},
+		{`[{"id":1},{"id":[2,}]`, `[1,[2,`},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := PluckField(&b, strings.NewReader(tt.in), "id", WithMinify(true), WithTrailingNewline(false))
+		if err == nil || b.String() != tt.want {
+			t.Errorf("PluckField(%s) = %q, %v, want %q and an error", tt.in, b.String(), err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// SyntaxError describes malformed input, locating the point at which it was
+// detected.
+type SyntaxError struct {
+	Line   int    // 1-based line number, or 0 if unknown
+	Column int    // 1-based column, counted in bytes, or 0 if unknown
+	Offset int64  // 0-based byte offset
+	Near   string // the input surrounding the error, on the same line
+	Err    error  // the description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%v at offset %d", e.Err, e.Offset)
+	}
+	return fmt.Sprintf("%v at line %d, column %d, near %q", e.Err, e.Line, e.Column, e.Near)
+}
+
+func (e *SyntaxError) Unwrap() error { return e.Err }
+
+// posWindow is the amount of recently read input retained in order to
+// locate errors.
+const posWindow = 64 << 10
+
+// nearContext is the number of bytes either side of an error included in
+// its Near field.
+const nearContext = 16
+
+// decoder is a source of tokens read from input.
+type decoder interface {
+	tokenSource
+
+	// InputOffset returns the offset of the input following the last
+	// token read.
+	InputOffset() int64
+}
+
+// jsonDecoder is a json.Decoder which locates syntax errors.
+type jsonDecoder struct {
+	*json.Decoder
+	pos      *posReader
+	maxToken int
+}
+
+func (d *jsonDecoder) Token() (json.Token, error) {
+	t, err := d.Decoder.Token()
+	if se, ok := err.(*json.SyntaxError); ok {
+		// the offset follows the offending byte
+		err = d.pos.locate(d.pos.window, max(se.Offset-1, 0), se)
+	}
+	if d.maxToken > 0 && tokenSize(t) > d.maxToken {
+		return nil, ErrTokenTooLarge
+	}
+	return t, err
+}
+
+// lines records the line structure of input preceding a window upon it.
+type lines struct {
+	base  int64 // the offset of the window
+	line  int   // the line number at the window, less one
+	start int64 // the offset of the start of that line
+}
+
+// discard records that the window no longer begins with b.
+func (l *lines) discard(b []byte) {
+	// bytes.Count is much faster than bytes.LastIndexByte, so only look
+	// for the last newline if there is one
+	if n := bytes.Count(b, []byte{'\n'}); n > 0 {
+		l.line += n
+		l.start = l.base + int64(bytes.LastIndexByte(b, '\n')) + 1
+	}
+	l.base += int64(len(b))
+}
+
+// locate returns a *SyntaxError for err, which was detected at offset off,
+// within or following window.
+func (l *lines) locate(window []byte, off int64, err error) *SyntaxError {
+	e := &SyntaxError{Offset: off, Err: err}
+	i := int(off - l.base)
+	if i < 0 || i > len(window) {
+		return e
+	}
+	seen := window[:i]
+	e.Line = l.line + bytes.Count(seen, []byte{'\n'}) + 1
+	start := 0
+	if j := bytes.LastIndexByte(seen, '\n'); j >= 0 {
+		start = j + 1
+		e.Column = i - start + 1
+	} else {
+		e.Column = int(off-l.start) + 1
+	}
+
+	lo, hi := max(start, i-nearContext), min(len(window), i+nearContext+1)
+	if j := bytes.IndexByte(window[i:hi], '\n'); j >= 0 {
+		hi = i + j
+	}
+	e.Near = string(bytes.TrimRight(window[lo:hi], "\r"))
+	return e
+}
+
+// posReader retains the input most recently read through it.
+type posReader struct {
+	r      io.Reader
+	window []byte
+	lines
+}
+
+func newPosReader(r io.Reader) *posReader {
+	return &posReader{r: r}
+}
+
+func (p *posReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.window = append(p.window, b[:n]...)
+	if len(p.window) > 2*posWindow {
+		drop := len(p.window) - posWindow
+		p.discard(p.window[:drop])
+		p.window = p.window[:copy(p.window, p.window[drop:])]
+	}
+	return n, err
+}
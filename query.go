@@ -0,0 +1,275 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidQuery is returned when a query is malformed.
+var ErrInvalidQuery = errors.New("jsonaux: invalid query")
+
+// WithFilter causes Format to emit only the values matched by query, as
+// Query does.
+func WithFilter(query string) Option {
+	return func(c *config) { c.filter = query }
+}
+
+// Query reads a value from r, and formats to w each value within it matched
+// by query, in input order. Successive matches are separated as set by
+// WithDocumentSeparator, and no output is produced if there are none. Values
+// which do not match are skipped as they are read, without being held in
+// memory.
+//
+// A query is a sequence of steps, in a subset of jq and JSONPath syntax,
+// optionally preceded by $:
+//
+//	.name      the member with the given key; ."name" or ["name"] for any key
+//	[n]        the element at index n
+//	[lo:hi]    the elements from index lo up to hi, either of which may be omitted
+//	.* or []   every member or element, as may be [*] or .[]
+//	..step     anything matched by step at any depth below
+//
+// so that .items[].id selects the id of each item, and ..id selects every
+// member named id. The empty query, . and $ each match the whole value. A
+// value matching the query is emitted whole, and not searched for further
+// matches. Negative indexes are not supported.
+func Query(w io.Writer, r io.Reader, query string, opts ...Option) error {
+	q, err := parseQuery(query)
+	if err != nil {
+		return err
+	}
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	n := 0
+	err = s.query(q, []int{0}, &n)
+	if err != nil {
+		return s.eof(err)
+	}
+	if n == 0 {
+		return s.Flush()
+	}
+	return s.end()
+}
+
+// step is a single step of a query.
+type step struct {
+	deep   bool   // whether the step matches at any depth below
+	any    bool   // whether the step matches every member and element
+	named  bool   // whether the step matches the member with key name
+	name   string // the key matched
+	lo, hi int    // the range of indexes matched, with hi < 0 if unbounded
+}
+
+// matches reports whether the step matches the member with the given key, or
+// if it is not a member, the element at index i.
+func (st step) matches(key string, member bool, i int) bool {
+	switch {
+	case st.any:
+		return true
+	case member:
+		return st.named && st.name == key
+	}
+	return !st.named && i >= st.lo && (st.hi < 0 || i < st.hi)
+}
+
+// query formats each value matched within the next value, having reached it
+// at each of the given positions within q, counting the matches in n.
+func (s *state) query(q []step, p []int, n *int) error {
+	for _, i := range p {
+		if i == len(q) {
+			if *n > 0 {
+				s.flushComment()
+				s.WriteString(s.docSep)
+			}
+			*n++
+			err := s.match()
+			if err != nil {
+				return err
+			}
+			return s.progress()
+		}
+	}
+	t, err := s.token()
+	if err != nil {
+		return err
+	}
+	switch t {
+	case json.Delim('{'):
+		s.push(object)
+	case json.Delim('['):
+		s.push(array)
+	default:
+		return nil
+	}
+	defer s.pop()
+	for i := 0; s.More(); i++ {
+		var k json.Token
+		if t == json.Delim('{') {
+			s.between()
+			k, err = s.token()
+			if err != nil {
+				return err
+			}
+			s.member(k.(string))
+		} else {
+			s.elem()
+		}
+		key, member := k.(string)
+		if next := advance(q, p, key, member, i); len(next) > 0 {
+			err = s.query(q, next, n)
+		} else {
+			err = s.skip()
+		}
+		if err != nil {
+			return err
+		}
+	}
+	// this will be '}' or ']'
+	_, err = s.token()
+	return err
+}
+
+// match formats the next value as a top-level value, reporting errors within
+// it at their path within the whole input.
+func (s *state) match() error {
+	outer := s.stack
+	s.prefix, s.stack = outer.path(), outer[len(outer):]
+	defer func() { s.prefix, s.stack = "", outer }()
+	return s.any()
+}
+
+// advance returns the positions within q reached from those in p by the
+// member with the given key, or if it is not a member, the element at
+// index i.
+func advance(q []step, p []int, key string, member bool, i int) []int {
+	var next []int
+	add := func(j int) {
+		for _, k := range next {
+			if k == j {
+				return
+			}
+		}
+		next = append(next, j)
+	}
+	for _, j := range p {
+		if q[j].deep {
+			add(j)
+		}
+		if q[j].matches(key, member, i) {
+			add(j + 1)
+		}
+	}
+	return next
+}
+
+// parseQuery returns the steps of a query.
+func parseQuery(query string) ([]step, error) {
+	str := strings.TrimPrefix(query, "$")
+	if str == "" || str == "." {
+		return nil, nil
+	}
+	var q []step
+	for str != "" {
+		var st step
+		switch {
+		case strings.HasPrefix(str, ".."):
+			st.deep = true
+			str = str[2:]
+		case str[0] == '.':
+			str = str[1:]
+		case str[0] != '[':
+			return nil, invalidQuery(query)
+		}
+		var ok bool
+		switch {
+		case str == "":
+			ok = false
+		case str[0] == '[':
+			str, ok = st.bracket(str[1:])
+		case str[0] == '*':
+			st.any, str, ok = true, str[1:], true
+		case str[0] == '"':
+			str, ok = st.quoted(str)
+		default:
+			i := strings.IndexAny(str, ".[")
+			if i < 0 {
+				i = len(str)
+			}
+			st.named, st.name, str, ok = true, str[:i], str[i:], i > 0
+		}
+		if !ok {
+			return nil, invalidQuery(query)
+		}
+		q = append(q, st)
+	}
+	return q, nil
+}
+
+// bracket parses the remainder of a bracketed step, returning the input
+// following it.
+func (st *step) bracket(str string) (string, bool) {
+	if str != "" && str[0] == '"' {
+		rest, ok := st.quoted(str)
+		if !ok || rest == "" || rest[0] != ']' {
+			return "", false
+		}
+		return rest[1:], true
+	}
+	i := strings.IndexByte(str, ']')
+	if i < 0 {
+		return "", false
+	}
+	in, rest := str[:i], str[i+1:]
+	if in == "" || in == "*" {
+		st.any = true
+		return rest, true
+	}
+	lo, hi, isRange := strings.Cut(in, ":")
+	var ok bool
+	st.lo, ok = queryIndex(lo, 0)
+	if !ok {
+		return "", false
+	}
+	st.hi = st.lo + 1
+	if isRange {
+		st.hi, ok = queryIndex(hi, -1)
+	}
+	return rest, ok
+}
+
+// quoted parses a step naming a key as a JSON string, returning the input
+// following it.
+func (st *step) quoted(str string) (string, bool) {
+	end := 1
+	for end < len(str) && str[end] != '"' {
+		if str[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(str) {
+		return "", false
+	}
+	err := json.Unmarshal([]byte(str[:end+1]), &st.name)
+	st.named = true
+	return str[end+1:], err == nil
+}
+
+// queryIndex parses an array index within a query, returning def if it is
+// empty.
+func queryIndex(str string, def int) (int, bool) {
+	if str == "" {
+		return def, true
+	}
+	i, err := strconv.Atoi(str)
+	return i, err == nil && i >= 0
+}
+
+func invalidQuery(query string) error {
+	return fmt.Errorf("%w: %q", ErrInvalidQuery, query)
+}
@@ -0,0 +1,90 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestQueryIndexes(t *testing.T) {
+	const in = `{"items":[{"secret":0},{"secret":1},{"secret":2},{"secret":3}],` +
+		`"grid":[[1,2],[3,4,5]],"named":{"2":"member"}}`
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`.items[2].secret`, "2\n"},
+		{`.items[0].secret`, "0\n"},
+		{`.items[4].secret`, ""},
+		{`.items[1:3].secret`, "1\n2\n"},
+		{`.items[*].secret`, "0\n1\n2\n3\n"},
+		{`.items[].secret`, "0\n1\n2\n3\n"},
+		{`.items.*.secret`, "0\n1\n2\n3\n"},
+		// names never match array elements, even numeric ones
+		{`.items.secret`, ""},
+		{`.items."2".secret`, ""},
+		{`.items["2"].secret`, ""},
+		{`.named."2"`, "\"member\"\n"},
+		// nor do indexes match object members
+		{`.named[2]`, ""},
+		// nested arrays
+		{`.grid[1][2]`, "5\n"},
+		{`.grid[0][2]`, ""},
+		{`.grid[*][1]`, "2\n4\n"},
+		{`.grid[1][*]`, "3\n4\n5\n"},
+		{`..[2]`, "{ \"secret\": 2\n}\n5\n"},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		err := Query(&b, strings.NewReader(in), tt.query)
+		if err != nil {
+			t.Errorf("Query(%s): %v", tt.query, err)
+			continue
+		}
+		if got := b.String(); got != tt.want {
+			t.Errorf("Query(%s) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestQueryErrorPaths(t *testing.T) {
+	const in = "{\"items\":[{\"id\":1},{\"id\":\"\xff\",\"x\":{\"long\":1,\"x\":2,\"x\":3}}]}"
+	tests := []struct {
+		query string
+		opt   Option
+		want  error
+		path  string
+	}{
+		// within a match
+		{`.items[].id`, WithInvalidUTF8(UTF8Error), ErrInvalidUTF8, "/items/1/id"},
+		{`..x`, WithMaxKeyLength(3), ErrKeyTooLong, "/items/1/x"},
+		{`.items[1]`, WithDuplicateKeys(DuplicateKeysError), ErrDuplicateKey, "/items/1/x/x"},
+		// within a value skipped
+		{`.items[0].id`, WithInvalidUTF8(UTF8Error), ErrInvalidUTF8, "/items/1"},
+		{`.items[].x`, WithInvalidUTF8(UTF8Error), ErrInvalidUTF8, "/items/1/id"},
+	}
+	for _, tt := range tests {
+		for name, err := range map[string]error{
+			"Query":  Query(new(strings.Builder), strings.NewReader(in), tt.query, tt.opt),
+			"Format": Format(new(strings.Builder), strings.NewReader(in), WithFilter(tt.query), tt.opt),
+		} {
+			var pe *PathError
+			if !errors.As(err, &pe) || pe.Path != tt.path || !errors.Is(err, tt.want) {
+				t.Errorf("%s(%s) = %v, want %v at %q", name, tt.query, err, tt.want, tt.path)
+			}
+		}
+	}
+
+	// the paths given to mappers are likewise within the whole input
+	var paths []string
+	mapper := func(path string, t json.Token) json.Token {
+		paths = append(paths, path)
+		return t
+	}
+	var b strings.Builder
+	err := Query(&b, strings.NewReader(`{"a":[{"b":[1]},{"b":{"c":2}}]}`), `.a[].b`, WithValueMapper(mapper), WithMinify(true))
+	if want := "/a/0/b/0 /a/1/b/c"; err != nil || strings.Join(paths, " ") != want || b.String() != "[1]\n{\"c\":2}\n" {
+		t.Errorf("Query with a value mapper = %q, %v, saw paths %q, want %q", b.String(), err, paths, want)
+	}
+}
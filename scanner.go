@@ -0,0 +1,67 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Scanner reads the tokens of a JSON value one at a time, reporting the
+// location of each within the value. It is a lower level alternative to
+// formatting, for use in building other tools.
+type Scanner struct {
+	dec decoder
+	stack
+}
+
+// NewScanner returns a Scanner reading from r. Numbers are returned as
+// json.Number values.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{dec: newDecoder(r, newConfig(nil)), stack: make(stack, 0, 64)}
+}
+
+// Next returns the next token along with the JSON Pointer of the value to
+// which it belongs. The delimiters of an object or array share the path of
+// that composite, and an object key, returned as a MemberKey to distinguish
+// it from a string value, shares the path of the member it begins. At the
+// end of the input, Next returns io.EOF.
+func (s *Scanner) Next() (path string, tok json.Token, err error) {
+	tok, err = s.dec.Token()
+	if err != nil {
+		return "", nil, err
+	}
+	switch tok {
+	case json.Delim('}'), json.Delim(']'):
+		s.pop()
+		path = s.path()
+		s.done()
+		return path, tok, nil
+	}
+
+	switch s.top() {
+	case object:
+		if !s.current() {
+			k := tok.(string)
+			s.member(k)
+			return s.path(), MemberKey(k), nil
+		}
+	case array:
+		s.elem()
+	}
+	path = s.path()
+	switch tok {
+	case json.Delim('{'):
+		s.push(object)
+	case json.Delim('['):
+		s.push(array)
+	default:
+		s.done()
+	}
+	return path, tok, nil
+}
+
+// done is called upon reading a complete value.
+func (s stack) done() {
+	if s.top() == object {
+		s.between()
+	}
+}
@@ -0,0 +1,65 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScanner(t *testing.T) {
+	type token struct {
+		path string
+		tok  json.Token
+	}
+	sc := NewScanner(strings.NewReader(`{"a":[1,{"b":"x","c":["b"]}],"d/e":"a","f":{}}`))
+	var got []token
+	for {
+		path, tok, err := sc.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, token{path, tok})
+	}
+	want := []token{
+		{"", json.Delim('{')},
+		{"/a", MemberKey("a")},
+		{"/a", json.Delim('[')},
+		{"/a/0", json.Number("1")},
+		{"/a/1", json.Delim('{')},
+		{"/a/1/b", MemberKey("b")},
+		{"/a/1/b", "x"},
+		{"/a/1/c", MemberKey("c")},
+		{"/a/1/c", json.Delim('[')},
+		{"/a/1/c/0", "b"},
+		{"/a/1/c", json.Delim(']')},
+		{"/a/1", json.Delim('}')},
+		{"/a", json.Delim(']')},
+		{"/d~1e", MemberKey("d/e")},
+		{"/d~1e", "a"},
+		{"/f", MemberKey("f")},
+		{"/f", json.Delim('{')},
+		{"/f", json.Delim('}')},
+		{"", json.Delim('}')},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Next returned\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestScannerErrors(t *testing.T) {
+	for _, in := range []string{"", `{"a":}`, `[1,`} {
+		sc := NewScanner(strings.NewReader(in))
+		var err error
+		for err == nil {
+			_, _, err = sc.Next()
+		}
+		if err == io.EOF && in != "" {
+			t.Errorf("Next(%q) ended without an error", in)
+		}
+	}
+}
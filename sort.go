@@ -0,0 +1,190 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"math/big"
+	"sort"
+)
+
+// WithSortArraysByKey sorts each array whose elements are all objects having
+// a member with the given key, ordering elements by that member's value.
+// Other arrays retain their original order. Since this reorders data, it is
+// off by default. Each array is buffered in memory in order to sort it.
+//
+// Values are ordered first by type: null, false, true, numbers, strings, and
+// finally objects and arrays. Numbers are compared by their numeric value,
+// and strings bytewise. Elements which compare equal retain their original
+// relative order, as do all objects and arrays.
+func WithSortArraysByKey(key string) Option {
+	return func(c *config) { c.sortBy = key }
+}
+
+// WithSortKeys controls whether the members of each object are sorted by
+// key, for deterministic, diff-friendly output. Keys are compared bytewise,
+// unless otherwise specified by WithKeyComparator or WithKeyPriority, and
+// members with duplicate keys retain their relative order. Each object is
+// buffered in memory in order to sort it.
+func WithSortKeys(sort bool) Option {
+	return func(c *config) { c.sortKeys = sort }
+}
+
+// WithSortKeysMaxDepth limits WithSortKeys to objects nested no more than n
+// levels deep, counting both objects and arrays as levels, and where a
+// top-level object is at level 1. Deeper objects retain their input order.
+// This saves the cost of sorting every level of large, deeply nested
+// documents when only the order of the outer levels matters, although the
+// outermost sorted objects are still buffered in full. Any comparator given
+// by WithKeyComparator or WithKeyPriority likewise applies only within the
+// sorted levels. A limit of zero or less, the default, means no limit.
+func WithSortKeysMaxDepth(n int) Option {
+	return func(c *config) { c.sortDepth = n }
+}
+
+// WithKeyComparator replaces the bytewise comparison of keys used by
+// WithSortKeys with cmp, which returns a negative number, zero, or a positive
+// number as a sorts before, alike, or after b. It applies to every object
+// sorted, which WithSortKeysMaxDepth may limit by depth.
+func WithKeyComparator(cmp func(a, b string) int) Option {
+	return func(c *config) { c.keyCompare = cmp }
+}
+
+// WithKeyPriority causes WithSortKeys to place members with any of the given
+// keys first, in the order listed, followed by the remaining members in their
+// usual order. For example, WithKeyPriority("id", "name") places any id and
+// name members at the start of each object.
+func WithKeyPriority(keys ...string) Option {
+	return func(c *config) {
+		c.keyPriority = make(map[string]int, len(keys))
+		for i := len(keys) - 1; i >= 0; i-- {
+			c.keyPriority[keys[i]] = i
+		}
+	}
+}
+
+// keyLess reports whether a member with key a sorts before one with key b.
+func (c *config) keyLess(a, b string) bool {
+	pa, oka := c.keyPriority[a]
+	pb, okb := c.keyPriority[b]
+	switch {
+	case oka && okb:
+		return pa < pb
+	case oka || okb:
+		return oka
+	case c.keyCompare != nil:
+		return c.keyCompare(a, b) < 0
+	}
+	return a < b
+}
+
+func (s *state) sortedArray() error {
+	d, err := readRest(checkedSource{s}, json.Delim('['))
+	if err != nil {
+		return err
+	}
+	keys := make([]json.Token, len(d.Elements))
+	for i, e := range d.Elements {
+		v := e.Lookup(s.sortBy)
+		if v == nil {
+			keys = nil
+			break
+		}
+		keys[i] = v.Token
+	}
+	if keys != nil {
+		sort.Stable(byKey{d.Elements, keys})
+	}
+	return s.replay(d)
+}
+
+func (s *state) sortedObject() error {
+	d, err := readRest(checkedSource{s}, json.Delim('{'))
+	if err != nil {
+		return err
+	}
+	if s.dupKeys == DuplicateKeysKeepLast {
+		s.keepLast(d)
+	}
+	sort.Stable(byName{d.Members, s.keyLess})
+	return s.replay(d)
+}
+
+type byName struct {
+	members []Member
+	less    func(a, b string) bool
+}
+
+func (b byName) Len() int           { return len(b.members) }
+func (b byName) Less(i, j int) bool { return b.less(b.members[i].Key, b.members[j].Key) }
+func (b byName) Swap(i, j int)      { b.members[i], b.members[j] = b.members[j], b.members[i] }
+
+type byKey struct {
+	elems []*Document
+	keys  []json.Token
+}
+
+func (b byKey) Len() int           { return len(b.elems) }
+func (b byKey) Less(i, j int) bool { return compareTokens(b.keys[i], b.keys[j]) < 0 }
+
+func (b byKey) Swap(i, j int) {
+	b.elems[i], b.elems[j] = b.elems[j], b.elems[i]
+	b.keys[i], b.keys[j] = b.keys[j], b.keys[i]
+}
+
+// compareTokens orders scalar tokens as documented by WithSortArraysByKey,
+// returning -1, 0, or +1. Delimiters compare equal to each other.
+func compareTokens(a, b json.Token) int {
+	ra, rb := tokenRank(a), tokenRank(b)
+	switch {
+	case ra != rb:
+		return compareInts(ra, rb)
+	case ra == rankNumber:
+		x, _ := new(big.Float).SetString(string(a.(json.Number)))
+		y, _ := new(big.Float).SetString(string(b.(json.Number)))
+		return x.Cmp(y)
+	case ra == rankString:
+		x, y := a.(string), b.(string)
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return +1
+		}
+	}
+	return 0
+}
+
+const (
+	rankNull = iota
+	rankFalse
+	rankTrue
+	rankNumber
+	rankString
+	rankComposite
+)
+
+func tokenRank(t json.Token) int {
+	switch t.(type) {
+	case nil:
+		return rankNull
+	case bool:
+		if t == true {
+			return rankTrue
+		}
+		return rankFalse
+	case json.Number:
+		return rankNumber
+	case string:
+		return rankString
+	}
+	return rankComposite
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return +1
+	}
+	return 0
+}
@@ -0,0 +1,51 @@
+package jsonaux
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+)
+
+// countingSource counts the tokens read from a tokenSource.
+type countingSource struct {
+	tokenSource
+	n int
+}
+
+func (c *countingSource) Token() (json.Token, error) {
+	c.n++
+	return c.tokenSource.Token()
+}
+
+func TestBufferedValuesAreChecked(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("{")
+	for i := range 1000 {
+		fmt.Fprintf(&b, `"k%d":[%d],`, 1000-i, i)
+	}
+	b.WriteString(`"k":{}}`)
+	in := b.String()
+
+	for _, opt := range []Option{
+		WithSortKeys(true),
+		WithDuplicateKeys(DuplicateKeysKeepLast),
+	} {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		c := newConfig([]Option{opt})
+		src := &countingSource{tokenSource: newDecoder(strings.NewReader(in), c)}
+		s := newState(io.Discard, src, c)
+		s.ctx = ctx
+		err := s.document()
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want %v", err, context.Canceled)
+		}
+		if src.n > checkInterval {
+			t.Errorf("read %d tokens after cancellation, want at most %d", src.n, checkInterval)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package jsonaux
+
+import "io"
+
+// Statistics describes the structure of a formatted document.
+type Statistics struct {
+	MaxDepth int // deepest nesting of objects and arrays; 0 for a scalar
+	Objects  int // number of objects
+	Arrays   int // number of arrays
+	Members  int // number of object members, across all objects
+	Scalars  int // number of scalar values, excluding object keys
+}
+
+func (st *Statistics) depth(n int) {
+	if n > st.MaxDepth {
+		st.MaxDepth = n
+	}
+}
+
+// FormatStats is like Format, but also reports statistics gathered while
+// formatting, allowing structural limits to be checked without a separate
+// pass over the input. On error, the statistics cover the input formatted
+// before the error occurred.
+func FormatStats(w io.Writer, r io.Reader, opts ...Option) (Statistics, error) {
+	c := newConfig(opts)
+
+	s := newState(w, newDecoder(r, c), c)
+	err := s.document()
+	return s.stats, err
+}
+
+// CountTokens reports the number of tokens read from r, as returned by
+// json.Decoder's Token method: scalars, object keys, and the delimiters of
+// objects and arrays, but not commas or colons. All values up to the end of
+// the input are counted. Nothing is retained beyond the decoder's buffer.
+func CountTokens(r io.Reader) (int, error) {
+	dec := newDecoder(r, newConfig(nil))
+	n := 0
+	for {
+		_, err := dec.Token()
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+}
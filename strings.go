@@ -0,0 +1,133 @@
+package jsonaux
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// WithEscapeJSSeparators controls whether U+2028 LINE SEPARATOR and U+2029
+// PARAGRAPH SEPARATOR are escaped within strings. Both are valid in JSON, but
+// terminate lines in JavaScript, breaking JSONP and inline <script> use. The
+// default is to escape them, as json.Marshal does.
+func WithEscapeJSSeparators(escape bool) Option {
+	return func(c *config) { c.rawJS = !escape }
+}
+
+// WithEscapeUnicode controls whether all non-ASCII characters within strings
+// are escaped, producing output which is pure ASCII. Characters outside the
+// Basic Multilingual Plane, such as most emoji, are escaped as a UTF-16
+// surrogate pair, as JSON requires. By default, they are written as UTF-8,
+// even where the input escapes them, so that \u00e9 is written as é.
+func WithEscapeUnicode(escape bool) Option {
+	return func(c *config) { c.ascii = escape }
+}
+
+// WithEscapeHTML controls whether <, >, and & are escaped within strings, as
+// \u003c, \u003e, and \u0026, so that the output may be embedded in HTML
+// safely. The default is to escape them, as json.Marshal does; disabling it
+// mirrors json.Encoder's SetEscapeHTML(false).
+func WithEscapeHTML(escape bool) Option {
+	return func(c *config) { c.noHTML = !escape }
+}
+
+// WithFoldStringWhitespace controls whether each run of whitespace within a
+// string value is replaced by a single space, for compact display. Object
+// keys are unaffected. Since this alters the data, it is disabled by default.
+func WithFoldStringWhitespace(fold bool) Option {
+	return func(c *config) { c.foldSpace = fold }
+}
+
+// foldSpace replaces each run of whitespace in str with a single space.
+func foldSpace(str string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range str {
+		if unicode.IsSpace(r) {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// quote writes str as a JSON string literal.
+func (s *state) quote(str string) {
+	s.buf = appendString(s.buf[:0], str, &s.config)
+	s.Write(s.buf)
+}
+
+const hex = "0123456789abcdef"
+
+// appendString appends the JSON encoding of str to buf. Aside from the
+// treatment specified by c, the result matches that of json.Marshal.
+func appendString(buf []byte, str string, c *config) []byte {
+	buf = append(buf, '"')
+	start := 0
+	for i := 0; i < len(str); {
+		b := str[i]
+		if b < utf8.RuneSelf {
+			if b >= ' ' && b != '"' && b != '\\' && (c.noHTML || b != '<' && b != '>' && b != '&') {
+				i++
+				continue
+			}
+			buf = append(buf, str[start:i]...)
+			switch b {
+			case '"', '\\':
+				buf = append(buf, '\\', b)
+			case '\b':
+				buf = append(buf, '\\', 'b')
+			case '\f':
+				buf = append(buf, '\\', 'f')
+			case '\n':
+				buf = append(buf, '\\', 'n')
+			case '\r':
+				buf = append(buf, '\\', 'r')
+			case '\t':
+				buf = append(buf, '\\', 't')
+			default:
+				buf = append(buf, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(str[i:])
+		switch {
+		case c.ascii:
+			buf = append(buf, str[start:i]...)
+			if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+				buf = appendEscape(buf, r1)
+				r = r2
+			}
+			buf = appendEscape(buf, r)
+		case r == utf8.RuneError && size == 1:
+			buf = append(buf, str[start:i]...)
+			buf = append(buf, "\ufffd"...)
+		case (r == '\u2028' || r == '\u2029') && !c.rawJS:
+			buf = append(buf, str[start:i]...)
+			buf = appendEscape(buf, r)
+		default:
+			i += size
+			continue
+		}
+		i += size
+		start = i
+	}
+	buf = append(buf, str[start:]...)
+	return append(buf, '"')
+}
+
+// appendEscape appends the \u escape of the UTF-16 code unit r.
+func appendEscape(buf []byte, r rune) []byte {
+	return append(buf, '\\', 'u', hex[r>>12&0xf], hex[r>>8&0xf], hex[r>>4&0xf], hex[r&0xf])
+}
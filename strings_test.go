@@ -0,0 +1,68 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestEscapeJSSeparators(t *testing.T) {
+	const in = "[\"a\u2028b\u2029c\"]"
+	tests := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{"default", nil, `["a\u2028b\u2029c"]`},
+		{"unicode unescaped", []Option{WithEscapeUnicode(false)}, `["a\u2028b\u2029c"]`},
+		{"unicode escaped", []Option{WithEscapeUnicode(true)}, `["a\u2028b\u2029c"]`},
+		{"disabled", []Option{WithEscapeJSSeparators(false)}, in},
+		{"disabled, unicode escaped", []Option{WithEscapeJSSeparators(false), WithEscapeUnicode(true)}, `["a\u2028b\u2029c"]`},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{WithMinify(true)}, tt.opts...)
+		if got := formatString(t, in, opts...); got != tt.want+"\n" {
+			t.Errorf("%s: Format = %q, want %q", tt.name, got, tt.want+"\n")
+		}
+	}
+}
+
+func TestEscapeUnicodeAstral(t *testing.T) {
+	strs := []string{"\U0001F600", "a\U0001D11Eb", "\U0002070E", "\U0010FFFF", "\u00e9\U0001F44D\U0001F3FD"}
+	for _, str := range strs {
+		lit, err := json.Marshal(str)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, escape := range []bool{false, true} {
+			got := formatString(t, string(lit), WithEscapeUnicode(escape), WithTrailingNewline(false))
+			var back string
+			if err := json.Unmarshal([]byte(got), &back); err != nil || back != str {
+				t.Errorf("escape %t: Format(%+q) = %s, which decodes to %+q, %v", escape, str, got, back, err)
+			}
+			ascii := strings.IndexFunc(got, func(r rune) bool { return r >= utf8.RuneSelf }) < 0
+			if escape != ascii || !escape && got != string(lit) {
+				t.Errorf("escape %t: Format(%+q) = %s", escape, str, got)
+			}
+		}
+	}
+	if got := formatString(t, `"\ud83d\ude00"`, WithEscapeUnicode(true)); got != "\"\\ud83d\\ude00\"\n" {
+		t.Errorf("surrogate pair = %s", got)
+	}
+	if got := formatString(t, `"\ud83d\ude00"`); got != "\"\U0001F600\"\n" {
+		t.Errorf("surrogate pair unescaped = %s", got)
+	}
+}
+
+func TestFoldStringWhitespace(t *testing.T) {
+	const in = "{\"a \\t b\":\"  one\\t\\ttwo   three \\n\\r\\n four\\t\"}"
+	got := formatString(t, in, WithFoldStringWhitespace(true), WithMinify(true))
+	if want := "{\"a \\t b\":\" one two three four \"}\n"; got != want {
+		t.Errorf("Format = %q, want %q", got, want)
+	}
+	got = formatString(t, in, WithMinify(true))
+	if want := in + "\n"; got != want {
+		t.Errorf("Format without folding = %q, want %q", got, want)
+	}
+}
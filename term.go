@@ -0,0 +1,21 @@
+package jsonaux
+
+import (
+	"io"
+	"os"
+)
+
+// isTerminal reports whether w is a terminal, with ok reporting whether this
+// could be determined. Only an *os.File may be a terminal; see isTerminalFile
+// for how each platform decides.
+func isTerminal(w io.Writer) (tty, ok bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false, false
+	}
+	tty, err := isTerminalFile(f)
+	if err != nil {
+		return false, false
+	}
+	return tty, true
+}
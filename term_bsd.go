@@ -0,0 +1,7 @@
+//go:build darwin || dragonfly || freebsd || netbsd || openbsd
+
+package jsonaux
+
+import "syscall"
+
+const ioctlGetTermios = syscall.TIOCGETA
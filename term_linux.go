@@ -0,0 +1,5 @@
+package jsonaux
+
+import "syscall"
+
+const ioctlGetTermios = syscall.TCGETS
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd && !windows
+
+package jsonaux
+
+import "os"
+
+// isTerminalFile reports whether f is a character device, lacking a better
+// test on this platform, so that devices such as /dev/null are taken to be
+// terminals.
+func isTerminalFile(f *os.File) (bool, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return false, err
+	}
+	return fi.Mode()&os.ModeCharDevice != 0, nil
+}
@@ -0,0 +1,57 @@
+package jsonaux
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestIsTerminal(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+	regular, err := os.Create(filepath.Join(t.TempDir(), "out.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer regular.Close()
+	type test struct {
+		name    string
+		w       io.Writer
+		tty, ok bool
+	}
+	tests := []test{
+		{"a pipe", w, false, true},
+		{"a regular file", regular, false, true},
+		{"a strings.Builder", new(strings.Builder), false, false},
+	}
+	if runtime.GOOS == "linux" || runtime.GOOS == "darwin" {
+		null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer null.Close()
+		// a character device, but not a terminal
+		tests = append(tests, test{os.DevNull, null, false, true})
+	}
+	for _, tt := range tests {
+		if tty, ok := isTerminal(tt.w); tty != tt.tty || ok != tt.ok {
+			t.Errorf("isTerminal(%s) = %v, %v, want %v, %v", tt.name, tty, ok, tt.tty, tt.ok)
+		}
+	}
+
+	// neither a newline nor color is written to a file which is not a terminal
+	err = Format(regular, strings.NewReader(`[1]`), WithSmartNewline(true), WithColorMode(ColorAuto), WithMinify(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b, err := os.ReadFile(regular.Name()); err != nil || string(b) != "[1]" {
+		t.Errorf("Format to a file, smart newline and ColorAuto = %q, %v, want %q", b, err, "[1]")
+	}
+}
@@ -0,0 +1,13 @@
+package jsonaux
+
+import (
+	"os"
+	"syscall"
+)
+
+// isTerminalFile reports whether f is a console, as its console mode can be
+// read.
+func isTerminalFile(f *os.File) (bool, error) {
+	var mode uint32
+	return syscall.GetConsoleMode(syscall.Handle(f.Fd()), &mode) == nil, nil
+}
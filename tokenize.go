@@ -0,0 +1,547 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// tokenBuffer is the initial size of a tokenizer's buffer.
+const tokenBuffer = 32 << 10
+
+// tokenizer is a decoder which scans input directly, in place of
+// json.Decoder, avoiding the copying and reflection it incurs per token. It
+// yields the same tokens as json.Decoder with UseNumber set. Each string and
+// number is still allocated, and boxed as a json.Token, as TokenSource
+// requires; object keys, which tend to recur, are interned to avoid this.
+// See the benchmarks in tokenize_test.go.
+type tokenizer struct {
+	r    io.Reader
+	rerr error // the error from r, once reading fails
+	err  error // the error last returned by Token
+
+	buf  []byte
+	pos  int   // the offset within buf of the next byte
+	mark int   // the offset within buf of the current token
+	off  int64 // the input offset following the last token
+	lines
+
+	maxToken int // the size limit for strings and numbers, if positive
+
+	state tokenState
+	stack []byte // the opening delimiter of each enclosing composite
+	str   []byte // the scratch buffer for unquoting strings
+	keys  map[string]json.Token
+}
+
+// tokenState is what a tokenizer expects to read next.
+type tokenState uint8
+
+const (
+	tokenTopValue    tokenState = iota
+	tokenArrayStart             // an element or ]
+	tokenArrayValue             // an element following a comma
+	tokenArrayComma             // a comma or ]
+	tokenObjectStart            // a key or }
+	tokenObjectKey              // a key following a comma
+	tokenObjectColon            // a colon
+	tokenObjectValue            // a member's value
+	tokenObjectComma            // a comma or }
+)
+
+func newTokenizer(r io.Reader) *tokenizer {
+	return &tokenizer{r: r, buf: make([]byte, 0, tokenBuffer)}
+}
+
+func (t *tokenizer) InputOffset() int64 { return t.off }
+
+func (t *tokenizer) More() bool {
+	c, err := t.peek()
+	return err == nil && c != ']' && c != '}'
+}
+
+func (t *tokenizer) Token() (json.Token, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+	tok, err := t.token()
+	if err != nil {
+		t.err = err
+		return nil, err
+	}
+	t.off = t.base + int64(t.pos)
+	return tok, nil
+}
+
+func (t *tokenizer) token() (json.Token, error) {
+	c, err := t.peek()
+	if err != nil {
+		return nil, t.end(err)
+	}
+	switch t.state {
+	case tokenArrayStart, tokenObjectStart:
+		if c == ']' && t.state == tokenArrayStart || c == '}' && t.state == tokenObjectStart {
+			return t.close()
+		}
+	case tokenArrayComma, tokenObjectComma:
+		closing, next, after := byte(']'), tokenArrayValue, "array element"
+		if t.state == tokenObjectComma {
+			closing, next, after = '}', tokenObjectKey, "object key:value pair"
+		}
+		switch c {
+		case closing:
+			return t.close()
+		case ',':
+			c, err = t.skip(next)
+		default:
+			return nil, t.fail("invalid character %s after %s", t.char(), after)
+		}
+	case tokenObjectColon:
+		if c != ':' {
+			return nil, t.fail("invalid character %s after object key", t.char())
+		}
+		c, err = t.skip(tokenObjectValue)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if t.state == tokenObjectStart || t.state == tokenObjectKey {
+		if c != '"' {
+			return nil, t.fail("invalid character %s looking for beginning of object key string", t.char())
+		}
+		t.state = tokenObjectColon
+		return t.key()
+	}
+	return t.value(c)
+}
+
+// skip reads past a comma or colon, entering state, and returns the byte
+// following any whitespace after it.
+func (t *tokenizer) skip(state tokenState) (byte, error) {
+	t.pos++
+	t.state = state
+	c, err := t.peek()
+	if err != nil {
+		return 0, t.end(err)
+	}
+	return c, nil
+}
+
+// value reads a value, or the opening delimiter of one, beginning with c.
+func (t *tokenizer) value(c byte) (json.Token, error) {
+	var tok json.Token
+	var err error
+	switch {
+	case c == '{' || c == '[':
+		t.pos++
+		t.stack = append(t.stack, c)
+		t.state = tokenArrayStart
+		if c == '{' {
+			t.state = tokenObjectStart
+		}
+		return json.Delim(c), nil
+	case c == '"':
+		tok, err = t.string()
+	case c == 't':
+		tok, err = true, t.literal("true")
+	case c == 'f':
+		tok, err = false, t.literal("false")
+	case c == 'n':
+		tok, err = nil, t.literal("null")
+	case c == '-' || '0' <= c && c <= '9':
+		tok, err = t.number()
+	default:
+		return nil, t.fail("invalid character %s looking for beginning of value", t.char())
+	}
+	t.after()
+	return tok, err
+}
+
+// close reads the closing delimiter of the current composite.
+func (t *tokenizer) close() (json.Token, error) {
+	c := t.buf[t.pos]
+	t.pos++
+	t.stack = t.stack[:len(t.stack)-1]
+	t.after()
+	return json.Delim(c), nil
+}
+
+// after updates the state following a value.
+func (t *tokenizer) after() {
+	switch {
+	case len(t.stack) == 0:
+		t.state = tokenTopValue
+	case t.stack[len(t.stack)-1] == '{':
+		t.state = tokenObjectComma
+	default:
+		t.state = tokenArrayComma
+	}
+}
+
+// end returns the error to report when reading fails between tokens.
+func (t *tokenizer) end(err error) error {
+	if err == io.EOF && t.state != tokenTopValue {
+		return t.fail("unexpected end of JSON input")
+	}
+	return err
+}
+
+// short returns the error to report when reading fails within a token.
+func (t *tokenizer) short() error {
+	if t.rerr == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return t.rerr
+}
+
+// fail returns a *SyntaxError located at the next byte.
+func (t *tokenizer) fail(format string, args ...any) error {
+	return t.failAt(t.pos, format, args...)
+}
+
+// failAt returns a *SyntaxError located at buf[i].
+func (t *tokenizer) failAt(i int, format string, args ...any) error {
+	return t.locate(t.buf, t.base+int64(i), fmt.Errorf(format, args...))
+}
+
+// char returns the quoted character at the next byte.
+func (t *tokenizer) char() string {
+	r, _ := utf8.DecodeRune(t.buf[t.pos:])
+	return strconv.QuoteRune(r)
+}
+
+// fill reads more input, reporting whether any was read. The current token,
+// and the input shortly preceding it, are retained.
+func (t *tokenizer) fill() bool {
+	for t.rerr == nil {
+		if len(t.buf) == cap(t.buf) {
+			if drop := t.mark - nearContext; drop >= len(t.buf)/2 {
+				t.discard(t.buf[:drop])
+				t.buf = t.buf[:copy(t.buf, t.buf[drop:])]
+				t.pos -= drop
+				t.mark -= drop
+			} else {
+				t.buf = slices.Grow(t.buf, len(t.buf))
+			}
+		}
+		n, err := t.r.Read(t.buf[len(t.buf):cap(t.buf)])
+		t.buf = t.buf[:len(t.buf)+n]
+		t.rerr = err
+		if n > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// peek skips whitespace, and returns the following byte.
+func (t *tokenizer) peek() (byte, error) {
+	for {
+		for ; t.pos < len(t.buf); t.pos++ {
+			switch c := t.buf[t.pos]; c {
+			case ' ', '\t', '\n', '\r':
+			default:
+				t.mark = t.pos
+				return c, nil
+			}
+		}
+		t.mark = t.pos
+		if !t.fill() {
+			return 0, t.rerr
+		}
+	}
+}
+
+// oversize reports whether the current token, less n bytes of quotes,
+// exceeds the size limit.
+func (t *tokenizer) oversize(n int) bool {
+	return t.maxToken > 0 && t.pos-t.mark-n > t.maxToken
+}
+
+// more ensures that the next byte is buffered, reporting whether it is.
+func (t *tokenizer) more() bool {
+	return t.pos < len(t.buf) || t.fill()
+}
+
+// literal reads the literal lit.
+func (t *tokenizer) literal(lit string) error {
+	t.pos++
+	for i := 1; i < len(lit); i++ {
+		if !t.more() {
+			return t.short()
+		}
+		if t.buf[t.pos] != lit[i] {
+			return t.fail("invalid character %s in literal %s (expecting %q)", t.char(), lit, lit[i])
+		}
+		t.pos++
+	}
+	return nil
+}
+
+// number reads a number, ending with the last byte which may belong to it.
+func (t *tokenizer) number() (json.Token, error) {
+	if t.buf[t.pos] == '-' {
+		t.pos++
+	}
+	if !t.more() {
+		return nil, t.short()
+	}
+	switch c := t.buf[t.pos]; {
+	case c == '0':
+		t.pos++
+	case '1' <= c && c <= '9':
+		t.digits()
+	default:
+		return nil, t.fail("invalid character %s in numeric literal", t.char())
+	}
+	if t.more() && t.buf[t.pos] == '.' {
+		t.pos++
+		if err := t.fraction(); err != nil {
+			return nil, err
+		}
+	}
+	if t.more() && (t.buf[t.pos] == 'e' || t.buf[t.pos] == 'E') {
+		t.pos++
+		if t.more() && (t.buf[t.pos] == '+' || t.buf[t.pos] == '-') {
+			t.pos++
+		}
+		if err := t.fraction(); err != nil {
+			return nil, err
+		}
+	}
+	if t.oversize(0) {
+		return nil, ErrTokenTooLarge
+	}
+	return json.Number(t.buf[t.mark:t.pos]), nil
+}
+
+// fraction reads the one or more digits required after a decimal point or
+// exponent.
+func (t *tokenizer) fraction() error {
+	if !t.more() {
+		return t.short()
+	}
+	if c := t.buf[t.pos]; c < '0' || c > '9' {
+		return t.fail("invalid character %s in numeric literal", t.char())
+	}
+	t.digits()
+	return nil
+}
+
+// digits reads any digits.
+func (t *tokenizer) digits() {
+	for {
+		for t.pos < len(t.buf) && '0' <= t.buf[t.pos] && t.buf[t.pos] <= '9' {
+			t.pos++
+		}
+		if t.pos < len(t.buf) || t.oversize(0) || !t.fill() {
+			return
+		}
+	}
+}
+
+// plain records the bytes which may appear unchanged within a string.
+var plain = func() (plain [256]bool) {
+	for c := ' '; c < utf8.RuneSelf; c++ {
+		plain[c] = c != '"' && c != '\\'
+	}
+	return plain
+}()
+
+// string reads a string.
+func (t *tokenizer) string() (json.Token, error) {
+	b, err := t.quoted()
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// key reads an object key. Keys recur, so the tokens of short ones are
+// retained and reused, sparing their allocation.
+func (t *tokenizer) key() (json.Token, error) {
+	b, err := t.quoted()
+	if err != nil || len(b) > maxKeyCache {
+		return string(b), err
+	}
+	if k, ok := t.keys[string(b)]; ok {
+		return k, nil
+	}
+	var k json.Token = string(b)
+	if t.keys == nil {
+		t.keys = make(map[string]json.Token)
+	}
+	if len(t.keys) < maxKeysCached {
+		t.keys[k.(string)] = k
+	}
+	return k, nil
+}
+
+// maxKeyCache and maxKeysCached bound the length and number of the keys a
+// tokenizer retains.
+const (
+	maxKeyCache   = 64
+	maxKeysCached = 1024
+)
+
+// quoted reads a string, returning its contents, which remain valid only
+// until reading continues.
+func (t *tokenizer) quoted() ([]byte, error) {
+	t.pos++
+	raw := true // whether the contents need no unquoting
+	for {
+		b := t.buf[t.pos:]
+		i := 0
+		for i < len(b) && plain[b[i]] {
+			i++
+		}
+		t.pos += i
+		if i == len(b) {
+			if t.oversize(1) {
+				return nil, ErrTokenTooLarge
+			}
+			if !t.fill() {
+				return nil, t.short()
+			}
+			continue
+		}
+		switch c := b[i]; {
+		case c == '"':
+			t.pos++
+			if t.oversize(2) {
+				return nil, ErrTokenTooLarge
+			}
+			if raw {
+				return t.buf[t.mark+1 : t.pos-1], nil
+			}
+			return t.unquote(t.mark+1, t.pos-1), nil
+		case c == '\\':
+			t.pos++
+			if !t.more() {
+				return nil, t.short()
+			}
+			if err := t.escaped(); err != nil {
+				return nil, err
+			}
+		case c < ' ':
+			return nil, t.fail("invalid character %s in string", t.char())
+		default:
+			t.pos++
+		}
+		raw = false
+	}
+}
+
+// escaped reads the remainder of an escape sequence following a backslash.
+func (t *tokenizer) escaped() error {
+	n := 1
+	switch t.buf[t.pos] {
+	case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+	case 'u':
+		n = 5
+	default:
+		return t.failAt(t.pos-1, "invalid escape sequence `%s` in string", t.buf[t.pos-1:t.pos+1])
+	}
+	start := t.pos - 1 - t.mark // as filling may move the token
+	for i := 1; i < n; i++ {
+		t.pos++
+		if !t.more() {
+			return t.short()
+		}
+		if _, ok := unhex(t.buf[t.pos]); !ok {
+			start += t.mark
+			return t.failAt(start, "invalid escape sequence `%s` in string", t.buf[start:t.pos+1])
+		}
+	}
+	t.pos++
+	return nil
+}
+
+// unquote returns the contents of the string held by buf[i:j], resolving
+// escapes, and replacing invalid UTF-8 and unpaired surrogates with U+FFFD.
+// Its escapes have already been validated.
+func (t *tokenizer) unquote(i, j int) []byte {
+	b := t.str[:0]
+	for i < j {
+		switch c := t.buf[i]; {
+		case c == '\\':
+			var n int
+			b, n = t.escape(b, i, j)
+			i += n
+		case c < utf8.RuneSelf:
+			b = append(b, c)
+			i++
+		default:
+			r, n := utf8.DecodeRune(t.buf[i:j])
+			b = utf8.AppendRune(b, r)
+			i += n
+		}
+	}
+	t.str = b
+	return b
+}
+
+// escape appends the character escaped at buf[i:j] to b, also returning the
+// number of bytes the escape occupies.
+func (t *tokenizer) escape(b []byte, i, j int) ([]byte, int) {
+	switch c := t.buf[i+1]; c {
+	case 'b':
+		b = append(b, '\b')
+	case 'f':
+		b = append(b, '\f')
+	case 'n':
+		b = append(b, '\n')
+	case 'r':
+		b = append(b, '\r')
+	case 't':
+		b = append(b, '\t')
+	case 'u':
+		r, _ := hex4(t.buf[i+2 : j])
+		if utf16.IsSurrogate(r) {
+			if r2, ok := hex4(t.buf[min(i+8, j):j]); ok && t.buf[i+6] == '\\' && t.buf[i+7] == 'u' {
+				if r := utf16.DecodeRune(r, r2); r != utf8.RuneError {
+					return utf8.AppendRune(b, r), 12
+				}
+			}
+			r = utf8.RuneError
+		}
+		return utf8.AppendRune(b, r), 6
+	default:
+		b = append(b, c)
+	}
+	return b, 2
+}
+
+// hex4 decodes the four hexadecimal digits beginning b.
+func hex4(b []byte) (rune, bool) {
+	if len(b) < 4 {
+		return 0, false
+	}
+	var r rune
+	for _, c := range b[:4] {
+		d, ok := unhex(c)
+		if !ok {
+			return 0, false
+		}
+		r = r<<4 | d
+	}
+	return r, true
+}
+
+// unhex decodes a hexadecimal digit.
+func unhex(c byte) (rune, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return rune(c - '0'), true
+	case 'a' <= c && c <= 'f':
+		return rune(c - 'a' + 10), true
+	case 'A' <= c && c <= 'F':
+		return rune(c - 'A' + 10), true
+	}
+	return 0, false
+}
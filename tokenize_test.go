@@ -0,0 +1,66 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// benchInput returns a document of several megabytes, resembling an API
+// response holding many records.
+var benchInput = sync.OnceValue(func() []byte {
+	var b bytes.Buffer
+	b.WriteString(`{"records":[`)
+	for i := range 20000 {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, `{"id":%d,"name":"record %d","active":%t,"score":%d.%03d,`, i, i, i%3 == 0, i%1000, i%997)
+		fmt.Fprintf(&b, `"tags":["alpha","beta","gamma"],"parent":null,"note":"line one\nline \"two\" é",`)
+		fmt.Fprintf(&b, `"location":{"lat":-%d.25,"lng":%d.5e-1,"label":"%x"}}`, i%90, i%180, i*7919)
+	}
+	b.WriteString(`]}`)
+	return b.Bytes()
+})
+
+// discardDecoder selects json.Decoder in place of the tokenizer.
+var discardDecoder = WithDecoder(func(*json.Decoder) {})
+
+func benchmarkFormat(b *testing.B, opts ...Option) {
+	in := benchInput()
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	for b.Loop() {
+		err := Format(io.Discard, bytes.NewReader(in), opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkFormat(b *testing.B)        { benchmarkFormat(b) }
+func BenchmarkFormatDecoder(b *testing.B) { benchmarkFormat(b, discardDecoder) }
+
+func benchmarkTokens(b *testing.B, opts ...Option) {
+	in := benchInput()
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	for b.Loop() {
+		dec := newDecoder(bytes.NewReader(in), newConfig(opts))
+		for {
+			_, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkTokens(b *testing.B)        { benchmarkTokens(b) }
+func BenchmarkTokensDecoder(b *testing.B) { benchmarkTokens(b, discardDecoder) }
@@ -0,0 +1,120 @@
+package jsonaux
+
+import (
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrInvalidUTF8 is returned, wrapped in a *PathError, when a string
+// contains invalid UTF-8 and UTF8Error is in effect.
+var ErrInvalidUTF8 = errors.New("jsonaux: invalid UTF-8 in string")
+
+// InvalidUTF8 determines the treatment of strings which contain byte
+// sequences that are not valid UTF-8.
+type InvalidUTF8 uint8
+
+const (
+	// UTF8Replace replaces each invalid byte with U+FFFD, as json.Decoder
+	// does. It is the default. The output does not record that a
+	// replacement took place.
+	UTF8Replace InvalidUTF8 = iota
+
+	// UTF8Error fails with ErrInvalidUTF8, reporting the path of the
+	// offending value. When the offending string is an object key, the
+	// path is that of the enclosing object.
+	UTF8Error
+
+	// UTF8Escape replaces each invalid byte with the code point having the
+	// same value, as though the byte were Latin-1. This retains the byte
+	// values in a recognizable form, but since JSON strings cannot hold
+	// arbitrary bytes, the result is indistinguishable from input which
+	// genuinely contained those code points.
+	UTF8Escape
+)
+
+// WithInvalidUTF8 selects the treatment of invalid UTF-8 within strings.
+// Modes other than UTF8Replace inspect the raw input before it is decoded,
+// adding some overhead.
+func WithInvalidUTF8(mode InvalidUTF8) Option {
+	return func(c *config) { c.utf8 = mode }
+}
+
+// utf8Reader inspects the strings within raw JSON input for invalid UTF-8,
+// before json.Decoder has the opportunity to replace it.
+type utf8Reader struct {
+	r    io.Reader
+	mode InvalidUTF8
+	in   []byte // input not yet inspected
+	out  []byte // inspected input not yet returned
+	err  error
+
+	str bool // within a string
+	esc bool // following a backslash within a string
+}
+
+func newUTF8Reader(r io.Reader, mode InvalidUTF8) *utf8Reader {
+	return &utf8Reader{r: r, mode: mode, in: make([]byte, 0, 4096)}
+}
+
+func (r *utf8Reader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.fill()
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+// fill reads more input and inspects as much of it as possible.
+func (r *utf8Reader) fill() {
+	buf := r.in[len(r.in):cap(r.in)]
+	n, err := r.r.Read(buf)
+	r.in = r.in[:len(r.in)+n]
+	r.out = r.out[:0]
+
+	i := 0
+scan:
+	for i < len(r.in) {
+		b := r.in[i]
+		switch {
+		case !r.str:
+			r.str = b == '"'
+		case r.esc:
+			r.esc = false
+		case b == '\\':
+			r.esc = true
+		case b == '"':
+			r.str = false
+		case b >= utf8.RuneSelf:
+			if !utf8.FullRune(r.in[i:]) && err == nil {
+				// wait for the remainder of the sequence
+				break scan
+			}
+			c, size := utf8.DecodeRune(r.in[i:])
+			if c != utf8.RuneError || size != 1 {
+				r.out = append(r.out, r.in[i:i+size]...)
+				i += size
+				continue
+			}
+			if r.mode == UTF8Error {
+				r.err = ErrInvalidUTF8
+				r.in = r.in[:0]
+				return
+			}
+			const hex = "0123456789abcdef"
+			r.out = append(r.out, '\\', 'u', '0', '0', hex[b>>4], hex[b&0xf])
+			i++
+			continue
+		}
+		r.out = append(r.out, b)
+		i++
+	}
+	r.in = r.in[:copy(r.in, r.in[i:])]
+	if err != nil {
+		r.err = err
+	}
+}
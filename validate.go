@@ -0,0 +1,134 @@
+package jsonaux
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTooManyErrors ends an ErrorList which was cut short by the limit set
+// with WithMaxErrors.
+var ErrTooManyErrors = errors.New("jsonaux: too many errors")
+
+// WithMaxErrors limits the number of errors collected by functions which
+// continue past errors, such as ValidateLines, which stop once n errors have
+// been collected. A limit of zero or less, the default, means no limit.
+func WithMaxErrors(n int) Option {
+	return func(c *config) { c.maxErrors = n }
+}
+
+// ErrorList is a list of errors, returned by functions which continue past
+// errors in order to report as many as possible.
+type ErrorList []error
+
+// Error returns the first error in the list, and the number of others. An
+// ErrTooManyErrors ending the list is not counted among them.
+func (l ErrorList) Error() string {
+	if len(l) == 0 {
+		return "no errors"
+	}
+	more := len(l) - 1
+	if more > 0 && l[more] == ErrTooManyErrors {
+		more--
+	}
+	if more == 0 {
+		return l[0].Error()
+	}
+	return fmt.Sprintf("%v (and %d more errors)", l[0], more)
+}
+
+// Unwrap returns the errors in the list.
+func (l ErrorList) Unwrap() []error { return l }
+
+// add appends err to the list, reporting whether the limit has been reached.
+func (l *ErrorList) add(err error, max int) bool {
+	*l = append(*l, err)
+	if max > 0 && len(*l) >= max {
+		*l = append(*l, ErrTooManyErrors)
+		return true
+	}
+	return false
+}
+
+// LineError records an error within a single line of input.
+type LineError struct {
+	Line int // 1-based line number
+	Err  error
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+
+func (e *LineError) Unwrap() error { return e.Err }
+
+// ValidateLines checks that each non-blank line of r, as in newline-delimited
+// JSON, holds exactly one value acceptable to Format under opts. Unlike
+// Format, it continues past invalid lines, returning an ErrorList of
+// *LineError values, or nil if every line is valid. Should the limits set by
+// WithMaxErrors or WithMaxDocuments be reached, the list ends with
+// ErrTooManyErrors or ErrMaxDocuments respectively; the latter is returned
+// alone if no lines were invalid.
+func ValidateLines(r io.Reader, opts ...Option) error {
+	c := newConfig(opts)
+
+	var errs ErrorList
+	br := bufio.NewReader(r)
+	docs := 0
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			if c.limit(docs) {
+				errs = append(errs, ErrMaxDocuments)
+				break
+			}
+			docs++
+			verr := validateOne(line, c)
+			if verr != nil && errs.add(&LineError{Line: n, Err: verr}, c.maxErrors) {
+				return errs
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+	}
+	switch {
+	case len(errs) == 0:
+		return nil
+	case len(errs) == 1 && errs[0] == ErrMaxDocuments:
+		return ErrMaxDocuments
+	}
+	return errs
+}
+
+// Validate checks that r holds exactly one value acceptable to Format under
+// opts. Malformed input is reported as a *SyntaxError, giving the line and
+// column at which it was detected.
+func Validate(r io.Reader, opts ...Option) error {
+	return validate(r, newConfig(opts))
+}
+
+// validateOne checks that buf holds exactly one value.
+func validateOne(buf []byte, c config) error {
+	return validate(bytes.NewReader(buf), c)
+}
+
+func validate(r io.Reader, c config) error {
+	dec := newDecoder(r, c)
+	err := format(io.Discard, dec, c)
+	if err != nil {
+		return err
+	}
+	_, err = dec.Token()
+	if err == io.EOF {
+		return nil
+	}
+	if err == nil {
+		err = ErrTrailingData
+	}
+	return err
+}
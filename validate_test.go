@@ -0,0 +1,37 @@
+package jsonaux
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestErrorListError(t *testing.T) {
+	a, b := errors.New("a"), errors.New("b")
+	tests := []struct {
+		l    ErrorList
+		want string
+	}{
+		{nil, "no errors"},
+		{ErrorList{a}, "a"},
+		{ErrorList{a, b}, "a (and 1 more errors)"},
+		{ErrorList{a, ErrTooManyErrors}, "a"},
+		{ErrorList{a, b, ErrTooManyErrors}, "a (and 1 more errors)"},
+	}
+	for _, tt := range tests {
+		if got := tt.l.Error(); got != tt.want {
+			t.Errorf("%#v.Error() = %q, want %q", []error(tt.l), got, tt.want)
+		}
+	}
+}
+
+func TestValidateLinesMaxErrors(t *testing.T) {
+	err := ValidateLines(strings.NewReader("x\n1\ny\nz\n"), WithMaxErrors(2))
+	var l ErrorList
+	if !errors.As(err, &l) || len(l) != 3 || !errors.Is(err, ErrTooManyErrors) {
+		t.Fatalf("got %v, want two errors and %v", err, ErrTooManyErrors)
+	}
+	if got := err.Error(); !strings.HasSuffix(got, "(and 1 more errors)") {
+		t.Errorf("Error() = %q, want it to count one more error", got)
+	}
+}
@@ -0,0 +1,203 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// MemberKey is the token passed to a WalkFunc, and returned by Scanner.Next,
+// for an object key, distinguishing it from a string value.
+type MemberKey string
+
+// WalkAction determines how Walk proceeds after calling a WalkFunc.
+type WalkAction uint8
+
+const (
+	// WalkContinue emits the token returned by the WalkFunc, and visits
+	// the contents of an object or array.
+	WalkContinue WalkAction = iota
+
+	// WalkSkip is like WalkContinue, but emits the contents of an object
+	// or array without visiting them.
+	WalkSkip
+
+	// WalkDrop omits the value, or for a key, the whole member. A
+	// dropped top-level value is emitted as null.
+	WalkDrop
+)
+
+// WalkFunc is called by Walk for each object key and each value, in input
+// order, before it is emitted. The path holds the reference tokens of the
+// JSON Pointer to the member or value, and is only valid until the function
+// returns; for a key, it includes the key itself. An object or array is
+// visited as its opening delimiter, before its contents.
+//
+// The returned token replaces tok: a key may be renamed by returning another
+// MemberKey, and a value replaced by returning any scalar token of the types
+// produced by json.Decoder with UseNumber set. An object or array is kept by
+// returning its delimiter unchanged, and replaced by returning a scalar. A
+// non-nil error stops the walk, and is returned by Walk.
+type WalkFunc func(path []string, tok json.Token) (json.Token, WalkAction, error)
+
+// Walk reads a value from r, calling fn for each key and value within it as
+// they are read, and formats the result as transformed by fn to w. Keys may
+// be renamed, and values replaced or dropped, without holding the value in
+// memory.
+func Walk(w io.Writer, r io.Reader, fn WalkFunc, opts ...Option) error {
+	c := newConfig(opts)
+	return format(w, &walker{src: newDecoder(r, c), fn: fn}, c)
+}
+
+// walker is a tokenSource yielding the tokens of src as transformed by fn.
+type walker struct {
+	src   tokenSource
+	fn    WalkFunc
+	path  []string
+	objs  []bool // whether each enclosing composite is an object
+	idx   []int  // the number of elements already seen in each
+	raw   int    // the depth within a composite emitted without visiting
+	queue []json.Token
+	err   error
+}
+
+func (w *walker) Token() (json.Token, error) {
+	w.fill()
+	if len(w.queue) == 0 {
+		return nil, w.err
+	}
+	t := w.queue[0]
+	w.queue = w.queue[1:]
+	return t, nil
+}
+
+func (w *walker) More() bool {
+	w.fill()
+	if len(w.queue) == 0 {
+		return false
+	}
+	t := w.queue[0]
+	return t != json.Delim('}') && t != json.Delim(']')
+}
+
+// fill ensures that the queue holds a token, unless reading fails.
+func (w *walker) fill() {
+	for len(w.queue) == 0 && w.err == nil {
+		w.err = w.step()
+	}
+}
+
+// step reads a key or value and queues the resulting tokens, if any.
+func (w *walker) step() error {
+	t, err := w.src.Token()
+	if err != nil {
+		return err
+	}
+	if w.raw > 0 {
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			w.raw++
+		case json.Delim('}'), json.Delim(']'):
+			w.raw--
+		}
+		w.queue = append(w.queue, t)
+		return nil
+	}
+	n := len(w.objs)
+	switch {
+	case t == json.Delim('}') || t == json.Delim(']'):
+		w.path, w.objs, w.idx = w.path[:n-1], w.objs[:n-1], w.idx[:n-1]
+		w.queue = append(w.queue, t)
+		return nil
+	case n > 0 && w.objs[n-1]:
+		w.path[n-1] = t.(string)
+		k, act, err := w.fn(w.path, MemberKey(w.path[n-1]))
+		if err != nil || act == WalkDrop {
+			if err == nil {
+				err = w.skip()
+			}
+			return err
+		}
+		key, ok := k.(MemberKey)
+		if !ok {
+			return fmt.Errorf("jsonaux: walk function returned %T for a key", k)
+		}
+		t, err = w.src.Token()
+		if err != nil {
+			return err
+		}
+		w.queue = append(w.queue, string(key))
+	case n > 0:
+		w.path[n-1] = strconv.Itoa(w.idx[n-1])
+		w.idx[n-1]++
+	}
+	return w.value(t)
+}
+
+// value visits the value beginning with t, queuing its replacement.
+func (w *walker) value(t json.Token) error {
+	v, act, err := w.fn(w.path, t)
+	if err != nil {
+		return err
+	}
+	if act == WalkDrop {
+		if len(w.objs) > 0 {
+			// the key, if any, is dropped too
+			w.queue = w.queue[:0]
+			return w.skipRest(t)
+		}
+		v = nil
+	}
+	d, ok := t.(json.Delim)
+	switch {
+	case !isScalar(v) && v != t:
+		return fmt.Errorf("jsonaux: walk function returned %T for %v", v, t)
+	case !ok:
+	case v != t:
+		if err := w.skipRest(t); err != nil {
+			return err
+		}
+	case act == WalkSkip:
+		w.raw = 1
+	default:
+		w.path = append(w.path, "")
+		w.objs = append(w.objs, d == '{')
+		w.idx = append(w.idx, 0)
+	}
+	w.queue = append(w.queue, v)
+	return nil
+}
+
+// skip discards the next value.
+func (w *walker) skip() error {
+	t, err := w.src.Token()
+	if err != nil {
+		return err
+	}
+	return w.skipRest(t)
+}
+
+// skipRest discards the remainder of the value beginning with t.
+func (w *walker) skipRest(t json.Token) error {
+	depth := 0
+	for {
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+		var err error
+		t, err = w.src.Token()
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
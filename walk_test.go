@@ -0,0 +1,157 @@
+package jsonaux
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// walkString returns the minified output of Walk for in.
+func walkString(t *testing.T, in string, fn WalkFunc, opts ...Option) (string, error) {
+	t.Helper()
+	var b strings.Builder
+	opts = append(opts, WithMinify(true), WithTrailingNewline(false))
+	err := Walk(&b, strings.NewReader(in), fn, opts...)
+	return b.String(), err
+}
+
+func TestWalk(t *testing.T) {
+	const doc = `{"user":{"name":"a","password":"x","tags":[1,2,3]},"drop":{"x":[1]},"keep":{"secret":1},"n":null,"last":[{"secret":2}]}`
+	tests := []struct {
+		name string
+		fn   func(p string, tok json.Token) (json.Token, WalkAction)
+		want string
+	}{
+		{"identity", func(p string, tok json.Token) (json.Token, WalkAction) {
+			return tok, WalkContinue
+		}, doc},
+		{"renaming a key", func(p string, tok json.Token) (json.Token, WalkAction) {
+			if tok == MemberKey("password") {
+				return MemberKey("pw"), WalkContinue
+			}
+			return tok, WalkContinue
+		}, `{"user":{"name":"a","pw":"x","tags":[1,2,3]},"drop":{"x":[1]},"keep":{"secret":1},"n":null,"last":[{"secret":2}]}`},
+		{"replacing a value", func(p string, tok json.Token) (json.Token, WalkAction) {
+			if _, ok := tok.(MemberKey); !ok && p == "user/password" {
+				return "***", WalkContinue
+			}
+			return tok, WalkContinue
+		}, `{"user":{"name":"a","password":"***","tags":[1,2,3]},"drop":{"x":[1]},"keep":{"secret":1},"n":null,"last":[{"secret":2}]}`},
+		{"replacing a composite", func(p string, tok json.Token) (json.Token, WalkAction) {
+			if p == "last/0" {
+				return json.Number("7"), WalkContinue
+			}
+			return tok, WalkContinue
+		}, `{"user":{"name":"a","password":"x","tags":[1,2,3]},"drop":{"x":[1]},"keep":{"secret":1},"n":null,"last":[7]}`},
+		{"dropping by key", func(p string, tok json.Token) (json.Token, WalkAction) {
+			if tok == MemberKey("drop") || tok == MemberKey("n") {
+				return tok, WalkDrop
+			}
+			return tok, WalkContinue
+		}, `{"user":{"name":"a","password":"x","tags":[1,2,3]},"keep":{"secret":1},"last":[{"secret":2}]}`},
+		{"dropping by value", func(p string, tok json.Token) (json.Token, WalkAction) {
+			if p == "user/tags/1" || tok == nil {
+				return tok, WalkDrop
+			}
+			return tok, WalkContinue
+		}, `{"user":{"name":"a","password":"x","tags":[1,3]},"drop":{"x":[1]},"keep":{"secret":1},"last":[{"secret":2}]}`},
+		{"skipping", func(p string, tok json.Token) (json.Token, WalkAction) {
+			if p == "keep" && tok == json.Delim('{') {
+				return tok, WalkSkip
+			}
+			if tok == MemberKey("secret") {
+				return tok, WalkDrop
+			}
+			return tok, WalkContinue
+		}, `{"user":{"name":"a","password":"x","tags":[1,2,3]},"drop":{"x":[1]},"keep":{"secret":1},"n":null,"last":[{}]}`},
+		{"dropping the top level", func(p string, tok json.Token) (json.Token, WalkAction) {
+			return tok, WalkDrop
+		}, `null`},
+	}
+	for _, tt := range tests {
+		got, err := walkString(t, doc, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+			v, act := tt.fn(strings.Join(path, "/"), tok)
+			return v, act, nil
+		})
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Walk = %s, %v, want %s", tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestWalkVisits(t *testing.T) {
+	const doc = `{"a":[1,{"b":null}],"c/d":{},"s":{"x":[2]}}`
+	var got []string
+	_, err := walkString(t, doc, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+		p := formatPointer(path)
+		switch tok := tok.(type) {
+		case MemberKey:
+			got = append(got, p+" key "+string(tok))
+		case json.Delim:
+			got = append(got, p+" "+tok.String())
+		default:
+			got = append(got, p+" value")
+		}
+		if p == "/s" && tok == json.Delim('{') {
+			return tok, WalkSkip, nil
+		}
+		return tok, WalkContinue, nil
+	})
+	want := []string{
+		" {",
+		"/a key a", "/a [", "/a/0 value", "/a/1 {", "/a/1/b key b", "/a/1/b value",
+		"/c~1d key c/d", "/c~1d {",
+		"/s key s", "/s {",
+	}
+	if err != nil || strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Walk visited\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWalkErrors(t *testing.T) {
+	boom := errors.New("boom")
+	tests := []struct {
+		in   string
+		fn   WalkFunc
+		want error // nil for any error
+	}{
+		{`[1]`, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+			if len(path) > 0 {
+				return nil, WalkContinue, boom
+			}
+			return tok, WalkContinue, nil
+		}, boom},
+		{`{"a":1}`, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+			if _, ok := tok.(MemberKey); ok {
+				return "a", WalkContinue, nil
+			}
+			return tok, WalkContinue, nil
+		}, nil},
+		{`[1]`, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+			return 1.5, WalkContinue, nil
+		}, nil},
+		{`[{"a":}]`, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+			return tok, WalkContinue, nil
+		}, nil},
+		{`{"a":[1,`, func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+			return tok, WalkDrop, nil
+		}, nil},
+	}
+	for _, tt := range tests {
+		got, err := walkString(t, tt.in, tt.fn)
+		if err == nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("Walk(%s) = %s, %v, want an error", tt.in, got, err)
+		}
+	}
+}
+
+func TestWalkOptions(t *testing.T) {
+	identity := func(path []string, tok json.Token) (json.Token, WalkAction, error) {
+		return tok, WalkContinue, nil
+	}
+	got, err := walkString(t, `{"b":{"z":1,"y":2},"a":[3,{}]}`, identity, WithSortKeys(true))
+	if want := `{"a":[3,{}],"b":{"y":2,"z":1}}`; err != nil || got != want {
+		t.Errorf("Walk with sorted keys = %s, %v, want %s", got, err, want)
+	}
+}
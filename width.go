@@ -0,0 +1,139 @@
+package jsonaux
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"unicode/utf8"
+)
+
+// WithLineWidth causes each object or array which fits within n columns when
+// laid out on a single line, as in {"x": 1, "y": 2}, to be laid out so, while
+// larger ones are expanded as usual. Columns are counted in characters,
+// including the indentation and any preceding key, but not any following
+// comma, and with a tab counting as a single column. Deciding the layout of
+// a composite requires its tokens to be buffered until either its end or the
+// width is reached. A width of zero or less, the default, disables this
+// layout.
+func WithLineWidth(n int) Option {
+	return func(c *config) { c.width = n }
+}
+
+// fitted lays out the remainder of a composite on a single line if it fits
+// within the line width, and expanded otherwise.
+func (s *state) fitted(d json.Delim) error {
+	src := s.tokenSource
+	defer func() { s.tokenSource = src }()
+
+	col := s.col
+	if s.top() == object {
+		col++ // the space following the colon
+	}
+	objs := []bool{d == '{'}
+	key := d == '{' // whether the next token is a key
+	opened := true  // whether the previous token opened a composite
+	col++
+	var toks []json.Token
+	for len(objs) > 0 && col <= s.width {
+		t, err := s.token()
+		if err != nil {
+			return err
+		}
+		toks = append(toks, t)
+		switch {
+		case t == json.Delim('}') || t == json.Delim(']'):
+			objs = objs[:len(objs)-1]
+			col++
+			opened = false
+			key = len(objs) > 0 && objs[len(objs)-1]
+			continue
+		case key:
+			if !opened {
+				col += 2
+			}
+			col += s.scalarWidth(t) + 2
+			opened, key = false, false
+			continue
+		case !opened && !objs[len(objs)-1]:
+			col += 2
+		}
+		switch t {
+		case json.Delim('{'), json.Delim('['):
+			objs = append(objs, t == json.Delim('{'))
+			col++
+			opened, key = true, t == json.Delim('{')
+		default:
+			col += s.scalarWidth(t)
+			opened, key = false, objs[len(objs)-1]
+		}
+	}
+
+	s.tokenSource = &chain{replay{toks}, src}
+	if len(objs) > 0 || col > s.width {
+		return s.expanded(d)
+	}
+	s.flat = s.depth() + 1
+	defer func() { s.flat = 0 }()
+	return s.stream(d)
+}
+
+// scalarWidth returns the number of columns occupied by a scalar token.
+func (s *state) scalarWidth(t json.Token) int {
+	switch t := t.(type) {
+	case nil:
+		return len(or(s.literals.Null, "null"))
+	case bool:
+		if t {
+			return len(or(s.literals.True, "true"))
+		}
+		return len(or(s.literals.False, "false"))
+	case json.Number:
+		return len(t)
+	}
+	s.buf = appendString(s.buf[:0], t.(string), &s.config)
+	return utf8.RuneCount(s.buf)
+}
+
+// output is a buffered writer which tracks the current column, if needed
+// for the line width.
+type output struct {
+	*bufio.Writer
+	track bool // whether the column is tracked
+	col   int  // characters written since the last newline
+}
+
+func (o *output) Write(p []byte) (int, error) {
+	if !o.track {
+		return o.Writer.Write(p)
+	}
+	if i := bytes.LastIndexByte(p, '\n'); i >= 0 {
+		o.col = utf8.RuneCount(p[i+1:])
+	} else {
+		o.col += utf8.RuneCount(p)
+	}
+	return o.Writer.Write(p)
+}
+
+func (o *output) WriteString(str string) (int, error) {
+	if !o.track {
+		return o.Writer.WriteString(str)
+	}
+	if i := strings.LastIndexByte(str, '\n'); i >= 0 {
+		o.col = utf8.RuneCountInString(str[i+1:])
+	} else {
+		o.col += utf8.RuneCountInString(str)
+	}
+	return o.Writer.WriteString(str)
+}
+
+func (o *output) WriteByte(b byte) error {
+	switch {
+	case !o.track:
+	case b == '\n':
+		o.col = 0
+	case utf8.RuneStart(b):
+		o.col++
+	}
+	return o.Writer.WriteByte(b)
+}
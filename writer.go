@@ -0,0 +1,276 @@
+package jsonaux
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// ErrStreamOrder is returned by StreamWriter when its methods are called in
+// an order not producing a single well-formed value.
+var ErrStreamOrder = errors.New("jsonaux: stream writer calls out of order")
+
+// tokenBatch is the number of tokens a StreamWriter gathers before passing
+// them to the formatter.
+const tokenBatch = 256
+
+// StreamWriter formats a single value to a writer as it is generated, call
+// by call, with the same output as Format would produce for it, but without
+// first holding the value in memory. Its methods return the StreamWriter, so
+// that calls may be chained, as in
+//
+//	sw.BeginObject().Field("k").Value(v).End()
+//
+// Errors, whether from misuse or from writing, are retained and reported by
+// Err and Close, and once one occurs, further calls have no effect. Close
+// must be called to complete the output; options which buffer composites,
+// such as WithSortKeys, hold them in memory as usual.
+type StreamWriter struct {
+	objs  []bool // whether each open composite is an object
+	key   bool   // whether the next value completes a member
+	begun bool   // whether the top-level value has begun
+	err   error
+	batch []json.Token
+
+	s      *state
+	q      *tokenQueue
+	ch     chan []json.Token
+	done   chan struct{}
+	closed bool  // whether ch is closed
+	ferr   error // the formatting error, once done is closed
+}
+
+// NewStreamWriter returns a StreamWriter formatting to w as configured by
+// opts. The formatting proceeds concurrently, in a goroutine which exits
+// upon Close.
+func NewStreamWriter(w io.Writer, opts ...Option) *StreamWriter {
+	sw := &StreamWriter{q: new(tokenQueue)}
+	sw.s = newState(w, sw.q, newConfig(opts))
+	sw.start()
+	return sw
+}
+
+// Reset discards the state of sw, and prepares it to format a new value to
+// w, with the same options, reusing its buffers. Reset does not complete or
+// flush the output to the previous writer, so Close should be called first;
+// otherwise, any output in progress is abandoned.
+func (sw *StreamWriter) Reset(w io.Writer) {
+	if !sw.closed {
+		close(sw.ch)
+	}
+	<-sw.done
+	sw.objs, sw.key, sw.begun, sw.err = sw.objs[:0], false, false, nil
+	sw.batch = sw.batch[:0]
+	sw.q.toks = nil
+	sw.s.reset(w)
+	sw.start()
+}
+
+// start begins formatting the tokens to be sent.
+func (sw *StreamWriter) start() {
+	sw.ch, sw.done, sw.closed, sw.ferr = make(chan []json.Token), make(chan struct{}), false, nil
+	sw.q.ch = sw.ch
+	go func() {
+		defer close(sw.done)
+		sw.ferr = sw.s.document()
+	}()
+}
+
+// BeginObject begins an object, to be completed by End.
+func (sw *StreamWriter) BeginObject() *StreamWriter {
+	return sw.begin('{')
+}
+
+// BeginArray begins an array, to be completed by End.
+func (sw *StreamWriter) BeginArray() *StreamWriter {
+	return sw.begin('[')
+}
+
+func (sw *StreamWriter) begin(d json.Delim) *StreamWriter {
+	if sw.value() {
+		sw.objs = append(sw.objs, d == '{')
+		sw.emit(d)
+	}
+	return sw
+}
+
+// Field begins an object member with the given key, to be completed by a
+// call to Value, BeginObject, or BeginArray.
+func (sw *StreamWriter) Field(key string) *StreamWriter {
+	n := len(sw.objs)
+	switch {
+	case sw.err != nil:
+	case n == 0 || !sw.objs[n-1] || sw.key:
+		sw.err = ErrStreamOrder
+	default:
+		sw.key = true
+		sw.emit(key)
+	}
+	return sw
+}
+
+// Value writes v as an array element, as the value of the member begun by
+// Field, or as the whole top-level value. A string, bool, json.Number, or nil
+// is written directly; anything else is encoded by json.Marshal, and its
+// encoding formatted in turn.
+func (sw *StreamWriter) Value(v any) *StreamWriter {
+	if !sw.value() {
+		return sw
+	}
+	if isScalar(v) {
+		sw.emit(v)
+		sw.after()
+		return sw
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		sw.err = err
+		return sw
+	}
+	dec := newTokenizer(bytes.NewReader(b))
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			sw.err = err
+			return sw
+		}
+		sw.emit(t)
+	}
+	sw.after()
+	return sw
+}
+
+// End completes the innermost open object or array.
+func (sw *StreamWriter) End() *StreamWriter {
+	n := len(sw.objs)
+	switch {
+	case sw.err != nil:
+	case n == 0 || sw.key:
+		sw.err = ErrStreamOrder
+	default:
+		d := json.Delim(']')
+		if sw.objs[n-1] {
+			d = '}'
+		}
+		sw.emit(d)
+		sw.objs = sw.objs[:n-1]
+		sw.after()
+	}
+	return sw
+}
+
+// Err returns the first error to have occurred, if any.
+func (sw *StreamWriter) Err() error {
+	select {
+	case <-sw.done:
+		if sw.err == nil {
+			return sw.ferr
+		}
+	default:
+	}
+	return sw.err
+}
+
+// Close completes the output, failing with ErrStreamOrder if the value is
+// incomplete, and returns the first error to have occurred. Calling Close
+// again returns the same result.
+func (sw *StreamWriter) Close() error {
+	if sw.closed {
+		if sw.err != nil {
+			return sw.err
+		}
+		return sw.ferr
+	}
+	if sw.err == nil && (!sw.begun || len(sw.objs) > 0) {
+		sw.err = ErrStreamOrder
+	}
+	sw.send()
+	close(sw.ch)
+	sw.closed = true
+	<-sw.done
+	if sw.err != nil {
+		return sw.err
+	}
+	return sw.ferr
+}
+
+// value reports whether a value may begin, recording ErrStreamOrder if not.
+func (sw *StreamWriter) value() bool {
+	n := len(sw.objs)
+	switch {
+	case sw.err != nil:
+		return false
+	case n == 0 && sw.begun, n > 0 && sw.objs[n-1] && !sw.key:
+		sw.err = ErrStreamOrder
+		return false
+	}
+	sw.key = false
+	sw.begun = true
+	return true
+}
+
+// after follows the completion of a value, passing on the tokens gathered
+// once the top-level value is complete.
+func (sw *StreamWriter) after() {
+	if len(sw.objs) == 0 {
+		sw.send()
+	}
+}
+
+// emit gathers a token, passing the batch on to the formatter once full.
+func (sw *StreamWriter) emit(t json.Token) {
+	sw.batch = append(sw.batch, t)
+	if len(sw.batch) >= tokenBatch {
+		sw.send()
+	}
+}
+
+// send passes the tokens gathered to the formatter, unless it has stopped.
+func (sw *StreamWriter) send() {
+	if len(sw.batch) == 0 {
+		return
+	}
+	select {
+	case sw.ch <- sw.batch:
+	case <-sw.done:
+		if sw.err == nil {
+			sw.err = sw.ferr
+		}
+	}
+	sw.batch = make([]json.Token, 0, tokenBatch)
+}
+
+// tokenQueue is a tokenSource yielding the batches of tokens received.
+type tokenQueue struct {
+	ch   chan []json.Token
+	toks []json.Token
+}
+
+func (q *tokenQueue) Token() (json.Token, error) {
+	if !q.fill() {
+		return nil, io.EOF
+	}
+	t := q.toks[0]
+	q.toks = q.toks[1:]
+	return t, nil
+}
+
+func (q *tokenQueue) More() bool {
+	return q.fill() && q.toks[0] != json.Delim('}') && q.toks[0] != json.Delim(']')
+}
+
+// fill ensures that a token is queued, reporting whether one is.
+func (q *tokenQueue) fill() bool {
+	for len(q.toks) == 0 {
+		b, ok := <-q.ch
+		if !ok {
+			return false
+		}
+		q.toks = b
+	}
+	return true
+}
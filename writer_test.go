@@ -0,0 +1,58 @@
+package jsonaux
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamWriterReset(t *testing.T) {
+	var a, b, c strings.Builder
+	sw := NewStreamWriter(&a, WithMinify(true))
+	sw.BeginObject().Field("a").Value(1).End()
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	sw.Reset(&b)
+	sw.BeginArray().Value("x").Value(true)
+	sw.Reset(&c)
+	sw.BeginArray().Value(2).End()
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := a.String(), "{\"a\":1}\n"; got != want {
+		t.Errorf("first output = %q, want %q", got, want)
+	}
+	if got := b.String(); got != "" {
+		t.Errorf("abandoned output = %q, want none", got)
+	}
+	if got, want := c.String(), "[2]\n"; got != want {
+		t.Errorf("output after Reset = %q, want %q", got, want)
+	}
+}
+
+func TestStreamWriterCloseTwice(t *testing.T) {
+	var b strings.Builder
+	sw := NewStreamWriter(&b)
+	sw.Value(1)
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := sw.Close(); err != nil {
+		t.Errorf("second Close = %v, want nil", err)
+	}
+	if got := b.String(); got != "1\n" {
+		t.Errorf("output = %q, want %q", got, "1\n")
+	}
+
+	sw = NewStreamWriter(&b)
+	sw.BeginArray()
+	first := sw.Close()
+	if first != ErrStreamOrder {
+		t.Fatalf("Close of incomplete value = %v, want %v", first, ErrStreamOrder)
+	}
+	if err := sw.Close(); err != first {
+		t.Errorf("second Close = %v, want %v", err, first)
+	}
+}